@@ -12,6 +12,8 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
+	"text/tabwriter"
 
 	log "github.com/sirupsen/logrus"
 
@@ -21,8 +23,8 @@ import (
 	"github.com/OpenSlides/openslides-go/redis"
 	"github.com/OpenSlides/openslides-search-service/pkg/config"
 	"github.com/OpenSlides/openslides-search-service/pkg/meta"
-	"github.com/OpenSlides/openslides-search-service/pkg/oserror"
 	"github.com/OpenSlides/openslides-search-service/pkg/search"
+	"github.com/OpenSlides/openslides-search-service/pkg/tracing"
 	"github.com/OpenSlides/openslides-search-service/pkg/web"
 	"golang.org/x/sys/unix"
 )
@@ -46,19 +48,16 @@ func signalContext() (context.Context, context.CancelFunc) {
 	return ctx, cancel
 }
 
-func run(cfg *config.Config) error {
-	log.SetLevel(cfg.LogLevel)
-	ctx, cancel := signalContext()
-	defer cancel()
-
+// loadModels loads the searched models and the related collections.
+func loadModels(cfg *config.Config) (meta.Collections, map[string]map[string]struct{}, error) {
 	collections, err := collection.Collections("./meta")
 	if err != nil {
-		return fmt.Errorf("loading models failed: %w", err)
+		return nil, nil, fmt.Errorf("loading models failed: %w", err)
 	}
 
 	models := meta.NewCollections(collections)
 	if err != nil {
-		return fmt.Errorf("loading models failed: %w", err)
+		return nil, nil, fmt.Errorf("loading models failed: %w", err)
 	}
 
 	// For text indexing we can only use string fields.
@@ -69,14 +68,99 @@ func run(cfg *config.Config) error {
 	if cfg.Models.Search != "" {
 		searchFilter, err := meta.Fetch[meta.Filters](cfg.Models.Search)
 		if err != nil {
-			return fmt.Errorf("loading search filters failed. %w", err)
+			return nil, nil, fmt.Errorf("loading search filters failed. %w", err)
 		}
 		containmentMap = searchFilter.ContainmentMap()
 		searchModels.Retain(searchFilter.Retain(false))
+		if err := searchModels.AddRelated(searchFilter); err != nil {
+			return nil, nil, fmt.Errorf("adding related fields failed: %w", err)
+		}
+		if err := searchModels.AddComputed(searchFilter); err != nil {
+			return nil, nil, fmt.Errorf("adding computed fields failed: %w", err)
+		}
 	} else {
 		searchModels.Retain(meta.RetainStrings())
 	}
 
+	return searchModels, containmentMap, nil
+}
+
+// loadBoosts loads the boosts configured in the search filters.
+func loadBoosts(cfg *config.Config) (*meta.Boosts, error) {
+	if cfg.Models.Search == "" {
+		return nil, nil
+	}
+	searchFilter, err := meta.Fetch[meta.Filters](cfg.Models.Search)
+	if err != nil {
+		return nil, fmt.Errorf("loading search filters failed. %w", err)
+	}
+	boosts, err := searchFilter.Boosts()
+	if err != nil {
+		return nil, fmt.Errorf("loading boosts failed: %w", err)
+	}
+	return boosts, nil
+}
+
+// loadQueryConfig loads the boosts and synonyms which are applied at query
+// time into the text index.
+func loadQueryConfig(cfg *config.Config, ti *search.TextIndex) error {
+	boosts, err := loadBoosts(cfg)
+	if err != nil {
+		return err
+	}
+
+	var synonyms *search.Synonyms
+	if cfg.Models.Synonyms != "" {
+		if synonyms, err = search.LoadSynonyms(cfg.Models.Synonyms); err != nil {
+			return err
+		}
+	}
+
+	ti.SetBoosts(boosts)
+	ti.SetSynonyms(synonyms)
+	return nil
+}
+
+// reloadQueryConfig reloads the boosts and synonyms on SIGHUP.
+func reloadQueryConfig(ctx context.Context, cfg *config.Config, ti *search.TextIndex) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, unix.SIGHUP)
+	defer signal.Stop(sig)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+			if err := loadQueryConfig(cfg, ti); err != nil {
+				log.Errorf("reloading query config failed: %v\n", err)
+				continue
+			}
+			log.Infof("reloaded boosts and synonyms\n")
+		}
+	}
+}
+
+func run(cfg *config.Config) error {
+	log.SetLevel(cfg.LogLevel)
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("setting up tracing failed: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Errorf("shutting down tracing failed: %v\n", err)
+		}
+	}()
+
+	searchModels, containmentMap, err := loadModels(cfg)
+	if err != nil {
+		return err
+	}
+
 	db := search.NewDatabase(cfg)
 	ti, err := search.NewTextIndex(cfg, db, searchModels)
 	if err != nil {
@@ -84,13 +168,15 @@ func run(cfg *config.Config) error {
 	}
 	defer ti.Close()
 
-	runtime.GC()
+	if err := loadQueryConfig(cfg, ti); err != nil {
+		return err
+	}
+	go reloadQueryConfig(ctx, cfg, ti)
 
 	qs, err := search.NewQueryServer(cfg, ti)
 	if err != nil {
 		return err
 	}
-	go qs.Run(ctx)
 
 	lookup := new(environment.ForProduction)
 	// Redis as message bus for datastore and logout events.
@@ -101,14 +187,87 @@ func run(cfg *config.Config) error {
 		return err
 	}
 
-	go authBackground(ctx, oserror.Handle)
+	authStatus := new(web.BackgroundStatus)
+	go authStatus.Run(ctx, authBackground)
+
+	health := &web.Health{Index: ti, DB: db, Auth: authStatus}
+
+	// Start the web server right away so that the health probes
+	// report the state of the index build.
+	webDone := make(chan error, 1)
+	go func() {
+		webDone <- web.Run(ctx, cfg, authService, qs, searchModels.CollectionRequestFields(), containmentMap, health)
+	}()
 
-	return web.Run(ctx, cfg, authService, qs, searchModels.CollectionRequestFields(), containmentMap)
+	if err := ti.Build(ctx); err != nil {
+		return fmt.Errorf("building text index failed: %w", err)
+	}
+
+	runtime.GC()
+
+	go qs.Run(ctx)
+
+	return <-webDone
+}
+
+// analyze runs an analyzer on the given text and prints the tokens.
+func analyze(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	analyzer := fs.String("analyzer", "de_html", "name of the analyzer")
+	field := fs.String("field", "", "use the analyzer of a field given as collection/field")
+	language := fs.String("language", "", "language of the field, defaults to the organization language")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s analyze [flags] text\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("text missing")
+	}
+
+	searchModels, _, err := loadModels(cfg)
+	if err != nil {
+		return err
+	}
+
+	ti, err := search.NewTextIndex(cfg, nil, searchModels)
+	if err != nil {
+		return err
+	}
+	if *field != "" {
+		if *analyzer, err = ti.FieldAnalyzer(*field, *language); err != nil {
+			return err
+		}
+	}
+
+	tokens, err := ti.Analyze(*analyzer, strings.Join(fs.Args(), " "))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("analyzer: %s\n", *analyzer)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "POSITION\tSTART\tEND\tTYPE\tTOKEN")
+	for _, token := range tokens {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%s\n",
+			token.Position, token.Start, token.End, token.Type, token.Term)
+	}
+	return w.Flush()
 }
 
 func main() {
 	flag.Parse()
 	cfg, err := config.GetConfig()
 	check(err)
-	check(run(cfg))
+
+	switch flag.Arg(0) {
+	case "analyze":
+		log.SetLevel(cfg.LogLevel)
+		check(analyze(cfg, flag.Args()[1:]))
+	default:
+		check(run(cfg))
+	}
 }
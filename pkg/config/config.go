@@ -32,6 +32,15 @@ const (
 	DefaultDBHost         = "localhost"
 	DefaultDBPort         = 5432
 	DefaultRestricterURL  = "http://autoupdate:9012/internal/autoupdate"
+	DefaultTraceExporter  = TracingNone
+	DefaultTraceFile      = "traces.json"
+)
+
+// Exporters of the traces.
+const (
+	TracingNone = "none"
+	TracingOTLP = "otlp"
+	TracingFile = "file"
 )
 
 // Web are the parameters for the web server.
@@ -50,10 +59,13 @@ type Index struct {
 }
 
 // Models are the paths to the YAML files containing the models
-// and the searched collections.
+// and the searched collections and to the optional synonyms and
+// decompound word files.
 type Models struct {
-	Models string
-	Search string
+	Models     string
+	Search     string
+	Synonyms   string
+	Decompound string
 }
 
 // Database are the credentials for the datavbase.
@@ -74,6 +86,8 @@ type Config struct {
 	Models      Models
 	Database    Database
 	Restricter  Restricter
+	Tracing     Tracing
+	Admin       Admin
 }
 
 // Restricter is the URL of the restricter to filter content by user id.
@@ -81,6 +95,20 @@ type Restricter struct {
 	URL string
 }
 
+// Tracing selects the exporter of the traces. The otlp exporter is
+// configured with the standard OTEL_EXPORTER_OTLP_* env vars. The file
+// exporter writes the traces as json to File.
+type Tracing struct {
+	Exporter string
+	File     string
+}
+
+// Admin contains the shared secret which grants internal services access
+// to the admin api. An empty secret only allows superadmins.
+type Admin struct {
+	Secret string
+}
+
 // GetConfig returns the configuration overwritten with env vars.
 func GetConfig() (*Config, error) {
 	cfg := &Config{
@@ -110,6 +138,10 @@ func GetConfig() (*Config, error) {
 		Restricter: Restricter{
 			URL: DefaultRestricterURL,
 		},
+		Tracing: Tracing{
+			Exporter: DefaultTraceExporter,
+			File:     DefaultTraceFile,
+		},
 	}
 	if err := cfg.fromEnv(); err != nil {
 		return nil, err
@@ -125,6 +157,7 @@ func (cfg *Config) fromEnv() error {
 		storeLogLevel   = store(logrus.ParseLevel)
 		storeDuration   = store(parseDuration)
 		storeDBPassword = store(parseSecretsFile(DefaultDBPasswordFile))
+		storeSecret     = store(parseSecretsFile(""))
 	)
 
 	return storeFromEnv([]storeEnv{
@@ -138,12 +171,17 @@ func (cfg *Config) fromEnv() error {
 		{"SEARCH_INDEX_UPDATE_INTERVAL", storeDuration(&cfg.Index.Update)},
 		{"MODELS_YML_FILE", storeString(&cfg.Models.Models)},
 		{"SEARCH_YML_FILE", storeString(&cfg.Models.Search)},
+		{"SEARCH_SYNONYMS_FILE", storeString(&cfg.Models.Synonyms)},
+		{"SEARCH_DECOMPOUND_FILE", storeString(&cfg.Models.Decompound)},
 		{"DATABASE_NAME", storeString(&cfg.Database.Database)},
 		{"DATABASE_USER", storeString(&cfg.Database.User)},
 		{"DATABASE_PASSWORD_FILE", storeDBPassword(&cfg.Database.Password)},
 		{"DATABASE_HOST", storeString(&cfg.Database.Host)},
 		{"DATABASE_PORT", storeInt(&cfg.Database.Port)},
 		{"RESTRICTER_URL", storeString(&cfg.Restricter.URL)},
+		{"SEARCH_TRACE_EXPORTER", storeString(&cfg.Tracing.Exporter)},
+		{"SEARCH_TRACE_FILE", storeString(&cfg.Tracing.File)},
+		{"SEARCH_ADMIN_SECRET_FILE", storeSecret(&cfg.Admin.Secret)},
 	})
 }
 
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/goccy/go-yaml"
+)
+
+// AnalysisKey is the key of the custom analysis within the search filters.
+const AnalysisKey = "_analysis"
+
+// Analysis contains the custom analyzers of the search filters and the
+// components they are composed of. Components are configured like in
+// bleve and need a type. Their names can be used by the analyzers next
+// to the ones built into bleve.
+type Analysis struct {
+	CharFilters  map[string]map[string]any `yaml:"char_filters,omitempty"`
+	Tokenizers   map[string]map[string]any `yaml:"tokenizers,omitempty"`
+	TokenMaps    map[string]map[string]any `yaml:"token_maps,omitempty"`
+	TokenFilters map[string]map[string]any `yaml:"token_filters,omitempty"`
+	Analyzers    map[string]*Analyzer      `yaml:"analyzers,omitempty"`
+}
+
+// Analyzer describes a custom analyzer.
+type Analyzer struct {
+	CharFilters  []string `yaml:"char_filters,omitempty"`
+	Tokenizer    string   `yaml:"tokenizer"`
+	TokenFilters []string `yaml:"token_filters,omitempty"`
+}
+
+// UnmarshalYAML parses the custom analysis out of the search filters.
+func (a *Analysis) UnmarshalYAML(node []byte) error {
+	var doc struct {
+		Analysis struct {
+			CharFilters  map[string]map[string]any `yaml:"char_filters,omitempty"`
+			Tokenizers   map[string]map[string]any `yaml:"tokenizers,omitempty"`
+			TokenMaps    map[string]map[string]any `yaml:"token_maps,omitempty"`
+			TokenFilters map[string]map[string]any `yaml:"token_filters,omitempty"`
+			Analyzers    map[string]*Analyzer      `yaml:"analyzers,omitempty"`
+		} `yaml:"_analysis"`
+	}
+	if err := yaml.Unmarshal(node, &doc); err != nil {
+		return err
+	}
+	*a = Analysis(doc.Analysis)
+
+	// Convert the components like JSON as bleve expects it, e.g. numbers
+	// as float64.
+	for _, components := range []map[string]map[string]any{
+		a.CharFilters, a.Tokenizers, a.TokenMaps, a.TokenFilters,
+	} {
+		for name, config := range components {
+			normalized, err := normalizeConfig(config)
+			if err != nil {
+				return fmt.Errorf("component %s: %w", name, err)
+			}
+			if _, ok := normalized["type"].(string); !ok {
+				return fmt.Errorf("component %s has no type", name)
+			}
+			components[name] = normalized
+		}
+	}
+
+	for name, analyzer := range a.Analyzers {
+		if analyzer == nil || analyzer.Tokenizer == "" {
+			return fmt.Errorf("analyzer %s has no tokenizer", name)
+		}
+	}
+	return nil
+}
+
+func normalizeConfig(config map[string]any) (map[string]any, error) {
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var normalized map[string]any
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
@@ -45,8 +45,10 @@ type CollectionRelation struct {
 
 // CollectionSearchableConfig contains per field config of a collection
 type CollectionSearchableConfig struct {
-	Type     *string `yaml:"type,omitempty"`
-	Analyzer *string `yaml:"analyzer,omitempty"`
+	Type     *string  `yaml:"type,omitempty"`
+	Analyzer *string  `yaml:"analyzer,omitempty"`
+	Boost    *float64 `yaml:"boost,omitempty"`
+	Phonetic *bool    `yaml:"phonetic,omitempty"`
 }
 
 // CollectionDescription is the collection format for search filters
@@ -56,6 +58,15 @@ type CollectionDescription struct {
 	Additional       []string                               `yaml:"additional"`
 	Contains         []string                               `yaml:"contains,omitempty"`
 	Relations        map[string]*CollectionRelation         `yaml:"relations,omitempty"`
+	Scope            map[string][]string                    `yaml:"scope,omitempty"`
+	Dates            []string                               `yaml:"dates,omitempty"`
+	Sortable         []string                               `yaml:"sortable,omitempty"`
+	Similar          []string                               `yaml:"similar,omitempty"`
+	Suggest          map[string]string                      `yaml:"suggest,omitempty"`
+	Related          map[string]*Related                    `yaml:"related,omitempty"`
+	Computed         map[string]string                      `yaml:"computed,omitempty"`
+	CollapseInto     *string                                `yaml:"collapse_into,omitempty"`
+	Boost            *float64                               `yaml:"boost,omitempty"`
 }
 
 // Collections is part of the meta model.
@@ -163,6 +174,10 @@ func (ms Collections) CollectionRequestFields() map[string]map[string]*Collectio
 	for _, k := range keys {
 		collections[k] = map[string]*CollectionRelation{}
 		for _, field := range ms[k].OrderedKeys() {
+			if ms[k].Fields[field].Derived() {
+				// Only part of the text index.
+				continue
+			}
 			collections[k][field] = ms[k].Fields[field].Relation
 		}
 	}
@@ -1,6 +1,9 @@
 package meta
 
 import (
+	"fmt"
+	"maps"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/goccy/go-yaml"
@@ -14,6 +17,19 @@ type Filter struct {
 	Additional  []string
 	Contains    map[string]struct{}
 	Relations   map[string]*CollectionRelation
+	Scope       map[string][]string
+	Dates       []string
+	Sortable    []string
+	Similar     []string
+	// Suggest maps relation fields to the collections of the objects
+	// suggested for similar objects.
+	Suggest  map[string]string
+	Related  map[string]*Related
+	Computed map[string]string
+	// CollapseInto is the generic relation field pointing to the object
+	// the hits of the collection are collapsed into.
+	CollapseInto *string
+	Boost        *float64
 }
 
 // Filters is a list of filters.
@@ -26,6 +42,8 @@ func (fs *Filters) UnmarshalYAML(node []byte) error {
 		return err
 	}
 
+	delete(fsm, AnalysisKey)
+
 	*fs = make(Filters, 0, len(fsm))
 	for k := range fsm {
 		relations := map[string]*CollectionRelation{}
@@ -39,12 +57,21 @@ func (fs *Filters) UnmarshalYAML(node []byte) error {
 		}
 
 		*fs = append(*fs, Filter{
-			Name:        k,
-			Items:       fsm[k].Searchable,
-			ItemsConfig: fsm[k].SearchableConfig,
-			Additional:  fsm[k].Additional,
-			Relations:   relations,
-			Contains:    contains,
+			Name:         k,
+			Items:        fsm[k].Searchable,
+			ItemsConfig:  fsm[k].SearchableConfig,
+			Additional:   fsm[k].Additional,
+			Relations:    relations,
+			Contains:     contains,
+			Scope:        fsm[k].Scope,
+			Dates:        fsm[k].Dates,
+			Sortable:     fsm[k].Sortable,
+			Similar:      fsm[k].Similar,
+			Suggest:      fsm[k].Suggest,
+			Related:      fsm[k].Related,
+			Computed:     fsm[k].Computed,
+			CollapseInto: fsm[k].CollapseInto,
+			Boost:        fsm[k].Boost,
 		})
 	}
 	return nil
@@ -66,6 +93,100 @@ func (fs Filters) ContainmentMap() map[string]map[string]struct{} {
 	return containment
 }
 
+// defaults holds the settings of collections which are used unless the
+// search configuration sets them. Field settings are used unless the
+// field sets them.
+var defaults = map[string]CollectionDescription{
+	"motion": {
+		SearchableConfig: map[string]*CollectionSearchableConfig{
+			"number": {Analyzer: ptr("identifier")},
+		},
+		Dates:    []string{"created", "last_modified"},
+		Sortable: []string{"sequential_number", "title", "created", "meeting_id"},
+		Similar:  []string{"title", "text", "reason"},
+		Suggest:  map[string]string{"tag_ids": "tag", "category_id": "motion_category"},
+	},
+	"meeting": {
+		Dates: []string{"start_time", "end_time"},
+	},
+	"mediafile": {
+		Dates: []string{"create_timestamp"},
+	},
+	"assignment": {
+		Sortable: []string{"sequential_number", "title", "meeting_id"},
+	},
+	"topic": {
+		Sortable: []string{"sequential_number", "title", "meeting_id"},
+	},
+	"agenda_item": {
+		SearchableConfig: map[string]*CollectionSearchableConfig{
+			"item_number": {Analyzer: ptr("identifier")},
+		},
+		Sortable:     []string{"weight", "meeting_id"},
+		CollapseInto: ptr("content_object_id"),
+	},
+	"list_of_speakers": {
+		CollapseInto: ptr("content_object_id"),
+	},
+	"poll": {
+		CollapseInto: ptr("content_object_id"),
+	},
+	"user": {
+		SearchableConfig: map[string]*CollectionSearchableConfig{
+			"first_name": {Phonetic: ptr(true)},
+			"last_name":  {Phonetic: ptr(true)},
+			"username":   {Phonetic: ptr(true)},
+		},
+	},
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// withDefaults returns the filter with the settings it leaves out taken
+// from the defaults of its collection.
+func (f Filter) withDefaults() Filter {
+	d := defaults[f.Name]
+	if f.Dates == nil {
+		f.Dates = d.Dates
+	}
+	if f.Sortable == nil {
+		f.Sortable = d.Sortable
+	}
+	if f.Similar == nil {
+		f.Similar = d.Similar
+	}
+	if f.Suggest == nil {
+		f.Suggest = d.Suggest
+	}
+	if f.CollapseInto == nil {
+		f.CollapseInto = d.CollapseInto
+	}
+
+	if len(d.SearchableConfig) > 0 {
+		config := maps.Clone(f.ItemsConfig)
+		if config == nil {
+			config = map[string]*CollectionSearchableConfig{}
+		}
+		for field, dc := range d.SearchableConfig {
+			c := CollectionSearchableConfig{}
+			if config[field] != nil {
+				c = *config[field]
+			}
+			if c.Analyzer == nil {
+				c.Analyzer = dc.Analyzer
+			}
+			if c.Phonetic == nil {
+				c.Phonetic = dc.Phonetic
+			}
+			config[field] = &c
+		}
+		f.ItemsConfig = config
+	}
+	return f
+}
+
 // Retain returns a keep function for [Retain] which also updates
 // if Members are searchable and adds their relation informations
 func (fs Filters) Retain(verbose bool) func(string, string, *Member) bool {
@@ -77,7 +198,14 @@ func (fs Filters) Retain(verbose bool) func(string, string, *Member) bool {
 	additional := map[key]struct{}{}
 	relations := map[key]*CollectionRelation{}
 	config := map[key]*CollectionSearchableConfig{}
+	scopes := map[key][]string{}
+	dates := map[key]struct{}{}
+	sortable := map[key]struct{}{}
+	collapse := map[key]struct{}{}
+	similar := map[key]struct{}{}
+	suggest := map[key]string{}
 	for _, m := range fs {
+		m = m.withDefaults()
 		for _, f := range m.Items {
 			keep[key{rel: m.Name, field: f}] = struct{}{}
 		}
@@ -93,6 +221,37 @@ func (fs Filters) Retain(verbose bool) func(string, string, *Member) bool {
 		for f, data := range m.Relations {
 			relations[key{rel: m.Name, field: f}] = data
 		}
+
+		scope := m.Scope
+		if scope == nil {
+			scope = DefaultScope
+		}
+		for kind, fields := range scope {
+			for _, f := range fields {
+				k := key{rel: m.Name, field: f}
+				scopes[k] = append(scopes[k], kind)
+			}
+		}
+
+		for _, f := range m.Dates {
+			dates[key{rel: m.Name, field: f}] = struct{}{}
+		}
+
+		for _, f := range m.Sortable {
+			sortable[key{rel: m.Name, field: f}] = struct{}{}
+		}
+
+		for _, f := range m.Similar {
+			similar[key{rel: m.Name, field: f}] = struct{}{}
+		}
+
+		for f, col := range m.Suggest {
+			suggest[key{rel: m.Name, field: f}] = col
+		}
+
+		if m.CollapseInto != nil && *m.CollapseInto != "" {
+			collapse[key{rel: m.Name, field: *m.CollapseInto}] = struct{}{}
+		}
 	}
 	return func(rk, fk string, m *Member) bool {
 		if _, ok := relations[key{rel: rk, field: fk}]; ok {
@@ -104,21 +263,80 @@ func (fs Filters) Retain(verbose bool) func(string, string, *Member) bool {
 				m.Type = *c.Type
 			}
 
-			m.Analyzer = c.Analyzer
+			if c.Analyzer != nil {
+				m.Analyzer = c.Analyzer
+			}
+			if c.Phonetic != nil {
+				m.Phonetic = *c.Phonetic
+			}
+		}
+
+		if kinds, ok := scopes[key{rel: rk, field: fk}]; ok {
+			m.Scopes = kinds
 		}
+		_, m.Date = dates[key{rel: rk, field: fk}]
+		_, m.Sortable = sortable[key{rel: rk, field: fk}]
+		_, m.CollapseInto = collapse[key{rel: rk, field: fk}]
+		_, m.Similar = similar[key{rel: rk, field: fk}]
+		m.Suggests = suggest[key{rel: rk, field: fk}]
 
 		if _, ok := additional[key{rel: rk, field: fk}]; ok {
 			m.Searchable = false
 			return true
 		}
 
-		_, ok := keep[key{rel: rk, field: fk}]
-		if !ok && verbose {
-			log.Printf("removing filtered %s.%s\n", rk, fk)
-		} else {
+		if _, ok := keep[key{rel: rk, field: fk}]; ok {
 			m.Searchable = true
+			return true
 		}
 
-		return ok
+		// Not searched itself but needed to restrict, sort or collapse
+		// searches or to find similar objects and suggestions.
+		if len(m.Scopes) > 0 || m.Date || m.Sortable || m.CollapseInto || m.Similar || m.Suggests != "" {
+			return true
+		}
+
+		if verbose {
+			log.Printf("removing filtered %s.%s\n", rk, fk)
+		}
+		return false
+	}
+}
+
+// Boosts contains the configured boosts of collections and their fields.
+type Boosts struct {
+	// Collections multiply the scores of the objects of a collection.
+	Collections map[string]float64
+	// Fields weight matches within a field of a collection higher.
+	Fields map[string]map[string]float64
+}
+
+// Boosts collects the configured boosts.
+func (fs Filters) Boosts() (*Boosts, error) {
+	boosts := &Boosts{
+		Collections: map[string]float64{},
+		Fields:      map[string]map[string]float64{},
+	}
+	for _, f := range fs {
+		if f.Boost != nil {
+			if *f.Boost <= 0 {
+				return nil, fmt.Errorf("boost of collection %s has to be positive", f.Name)
+			}
+			boosts.Collections[f.Name] = *f.Boost
+		}
+
+		for field, c := range f.ItemsConfig {
+			if c == nil || c.Boost == nil {
+				continue
+			}
+			if *c.Boost < 1 {
+				return nil, fmt.Errorf("boost of field %s.%s has to be at least 1", f.Name, field)
+			}
+			if boosts.Fields[f.Name] == nil {
+				boosts.Fields[f.Name] = map[string]float64{}
+			}
+			boosts.Fields[f.Name][field] = *c.Boost
+		}
 	}
+	return boosts, nil
 }
@@ -10,8 +10,22 @@ type Member struct {
 	Required   bool
 	Searchable bool
 	Analyzer   *string
+	Phonetic   bool
 	Relation   *CollectionRelation
-	Order      int32
+	Scopes     []string
+	Date       bool
+	Sortable   bool
+	// CollapseInto marks the field pointing to the object hits are
+	// collapsed into.
+	CollapseInto bool
+	// Similar marks the texts compared to find similar objects.
+	Similar bool
+	// Suggests is the collection of the objects the relation field holds
+	// if they are suggested for similar objects.
+	Suggests string
+	Related  *Related
+	Computed *Template
+	Order    int32
 }
 
 // Clone returns a deep copy.
@@ -23,6 +37,20 @@ func (m *Member) Clone() *Member {
 	}
 }
 
+// Indexed returns true if the member has to be part of the text index.
+// This is the case for searchable members and the ones needed to restrict
+// a search to a scope or a time range, to sort or collapse its results or
+// to find similar objects and suggestions.
+func (m *Member) Indexed() bool {
+	return m.Searchable || len(m.Scopes) > 0 || m.Date || m.Sortable || m.CollapseInto || m.Similar || m.Suggests != ""
+}
+
+// Derived returns true if the member is no column of the database but
+// filled for the text index.
+func (m *Member) Derived() bool {
+	return m.Related != nil || m.Computed != nil
+}
+
 // RetainStrings returns a function which keeps string type fields in [Retain].
 func RetainStrings() func(string, string, *Member) bool {
 	return func(k, fk string, f *Member) bool {
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package meta
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Related describes a field of an object holding texts of related objects,
+// e.g. the names of the submitters of a motion. The related objects are
+// reached by following the path starting at the object.
+type Related struct {
+	Path   []*RelationStep `yaml:"path"`
+	Fields []string        `yaml:"fields"`
+}
+
+// RelationStep leads from objects to related objects of a collection.
+// Either Via names the relation field of the objects holding the ids of
+// the related ones or Back names the relation field of the related objects
+// holding the ids of the objects.
+type RelationStep struct {
+	Collection string `yaml:"collection"`
+	Via        string `yaml:"via,omitempty"`
+	Back       string `yaml:"back,omitempty"`
+}
+
+// AddRelated adds the related fields configured in the search filters to
+// the collections. They are searchable lists of texts.
+func (ms Collections) AddRelated(fs Filters) error {
+	for _, f := range fs {
+		for _, name := range slices.Sorted(maps.Keys(f.Related)) {
+			related := f.Related[name]
+			col, ok := ms[f.Name]
+			if !ok {
+				return fmt.Errorf("related field %s.%s: collection is not indexed", f.Name, name)
+			}
+			if _, ok := col.Fields[name]; ok {
+				return fmt.Errorf("related field %s.%s: field exists", f.Name, name)
+			}
+			if err := related.validate(); err != nil {
+				return fmt.Errorf("related field %s.%s: %w", f.Name, name, err)
+			}
+			col.Fields[name] = &Member{
+				Type:       "string[]",
+				Searchable: true,
+				Related:    related,
+				Order:      fieldNum.Add(1),
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Related) validate() error {
+	if r == nil || len(r.Path) == 0 {
+		return fmt.Errorf("path is missing")
+	}
+	if len(r.Fields) == 0 {
+		return fmt.Errorf("fields are missing")
+	}
+	for i, step := range r.Path {
+		if step == nil || step.Collection == "" {
+			return fmt.Errorf("step %d has no collection", i+1)
+		}
+		if (step.Via == "") == (step.Back == "") {
+			return fmt.Errorf("step %d needs either via or back", i+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package meta
+
+// Scope kinds a search can be restricted to.
+const (
+	ScopeMeeting      = "meeting"
+	ScopeCommittee    = "committee"
+	ScopeOrganization = "organization"
+)
+
+// DefaultScope is used for collections without a scope configuration.
+// It maps a scope kind to the fields linking an object to it.
+var DefaultScope = map[string][]string{
+	ScopeMeeting:      {"meeting_id", "meeting_ids", "owner_id"},
+	ScopeCommittee:    {"committee_id", "committee_ids"},
+	ScopeOrganization: {"organization_id", "owner_id"},
+}
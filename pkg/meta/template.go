@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package meta
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Template computes a text from the fields of an object. Fields are
+// written in braces like "{first_name} {last_name}".
+type Template struct {
+	source string
+	// parts alternate between literal texts and field names, starting
+	// and ending with a literal.
+	parts []string
+}
+
+// ParseTemplate parses a template.
+func ParseTemplate(s string) (*Template, error) {
+	t := &Template{source: s}
+	rest := s
+	for {
+		start := strings.IndexAny(rest, "{}")
+		if start == -1 {
+			t.parts = append(t.parts, rest)
+			break
+		}
+		if rest[start] == '}' {
+			return nil, fmt.Errorf("template %q: unexpected }", s)
+		}
+		end := strings.IndexAny(rest[start+1:], "{}")
+		if end == -1 || rest[start+1+end] != '}' {
+			return nil, fmt.Errorf("template %q: unclosed {", s)
+		}
+		field := strings.TrimSpace(rest[start+1 : start+1+end])
+		if field == "" {
+			return nil, fmt.Errorf("template %q: empty field", s)
+		}
+		t.parts = append(t.parts, rest[:start], field)
+		rest = rest[start+1+end+1:]
+	}
+	if len(t.parts) == 1 {
+		return nil, fmt.Errorf("template %q: no fields", s)
+	}
+	return t, nil
+}
+
+// String returns the source of the template.
+func (t *Template) String() string {
+	return t.source
+}
+
+// Fields returns the names of the fields used by the template.
+func (t *Template) Fields() []string {
+	var fields []string
+	for i := 1; i < len(t.parts); i += 2 {
+		if !slices.Contains(fields, t.parts[i]) {
+			fields = append(fields, t.parts[i])
+		}
+	}
+	return fields
+}
+
+// Execute fills the template with the values of the fields. Empty fields
+// are left out together with the literal texts separating them, so
+// "{number}: {title}" becomes only the title without number. The literals at
+// the ends belong to the first and last field. The trailing one is only kept
+// if the last field was written with the literal before it, so
+// "{name} ({levels})" without name becomes only the levels.
+func (t *Template) Execute(value func(field string) string) string {
+	var b strings.Builder
+	last := -1
+	// leading tells if the last written field follows its literal.
+	leading := false
+	for i := 1; i < len(t.parts); i += 2 {
+		v := value(t.parts[i])
+		if v == "" {
+			continue
+		}
+		switch {
+		case last >= 0:
+			b.WriteString(t.parts[last+1])
+			leading = true
+		case i == 1:
+			b.WriteString(t.parts[0])
+			leading = true
+		default:
+			leading = false
+		}
+		b.WriteString(v)
+		last = i
+	}
+	if last == len(t.parts)-2 && leading {
+		b.WriteString(t.parts[len(t.parts)-1])
+	}
+	return b.String()
+}
+
+// AddComputed adds the computed fields configured in the search filters to
+// the collections. They are searchable strings.
+func (ms Collections) AddComputed(fs Filters) error {
+	for _, f := range fs {
+		for _, name := range slices.Sorted(maps.Keys(f.Computed)) {
+			col, ok := ms[f.Name]
+			if !ok {
+				return fmt.Errorf("computed field %s.%s: collection is not indexed", f.Name, name)
+			}
+			if _, ok := col.Fields[name]; ok {
+				return fmt.Errorf("computed field %s.%s: field exists", f.Name, name)
+			}
+			template, err := ParseTemplate(f.Computed[name])
+			if err != nil {
+				return fmt.Errorf("computed field %s.%s: %w", f.Name, name, err)
+			}
+			col.Fields[name] = &Member{
+				Type:       "string",
+				Searchable: true,
+				Computed:   template,
+				Order:      fieldNum.Add(1),
+			}
+		}
+	}
+	return nil
+}
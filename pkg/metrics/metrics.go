@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+// Package metrics defines the prometheus metrics of the search service.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "openslides_search"
+
+var (
+	// QueryDuration measures the time a query takes including the time
+	// waiting in the queue.
+	QueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "query_duration_seconds",
+		Help:      "Duration of search queries including the time in the queue.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, []string{"kind"})
+
+	// QueueDepth is the number of queries waiting in the queue.
+	QueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "query_queue_depth",
+		Help:      "Number of queries waiting to be processed.",
+	})
+
+	// QueueRejections counts the queries rejected because of a full queue.
+	QueueRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "query_queue_rejections_total",
+		Help:      "Number of queries rejected because the queue was full.",
+	})
+
+	// IndexUpdateDuration measures the incremental updates of the index.
+	IndexUpdateDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "index_update_duration_seconds",
+		Help:      "Duration of incremental index updates.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	})
+
+	// IndexUpdateLag measures the time between a change in the database
+	// and its arrival in the index.
+	IndexUpdateLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "index_update_lag_seconds",
+		Help:      "Age of the oldest entry of os_notify_log_t processed by an index update.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
+	})
+
+	// IndexLastUpdate is the time of the last successful index update.
+	IndexLastUpdate = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "index_last_update_timestamp_seconds",
+		Help:      "Unix time of the last successful index update.",
+	})
+
+	// IndexBuildDuration is the duration of the last full index build.
+	IndexBuildDuration = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "index_build_duration_seconds",
+		Help:      "Duration of the last full index build.",
+	})
+
+	// IndexDocuments is the number of indexed documents per collection.
+	IndexDocuments = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "index_documents",
+		Help:      "Number of indexed documents per collection.",
+	}, []string{"collection"})
+
+	// RestricterDuration measures the calls to the restricter.
+	RestricterDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "restricter_duration_seconds",
+		Help:      "Duration of calls to the restricter.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	})
+
+	// RestricterErrors counts the failed calls to the restricter.
+	RestricterErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "restricter_errors_total",
+		Help:      "Number of failed calls to the restricter.",
+	})
+)
+
+// Handler returns the http handler exposing the metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
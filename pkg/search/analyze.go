@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/mapping"
+)
+
+// Token is a term produced by an analyzer.
+type Token struct {
+	Term     string `json:"token"`
+	Start    int    `json:"start_offset"`
+	End      int    `json:"end_offset"`
+	Position int    `json:"position"`
+	Type     string `json:"type"`
+}
+
+var tokenTypes = map[analysis.TokenType]string{
+	analysis.AlphaNumeric: "alphanumeric",
+	analysis.Ideographic:  "ideographic",
+	analysis.Numeric:      "numeric",
+	analysis.DateTime:     "datetime",
+	analysis.Shingle:      "shingle",
+	analysis.Single:       "single",
+	analysis.Double:       "double",
+	analysis.Boolean:      "boolean",
+	analysis.IP:           "ip",
+}
+
+// FieldAnalyzer returns the name of the analyzer bound to a field in the
+// language. The field is given as collection/field. Without a language the
+// language of the organization is used.
+func (ti *TextIndex) FieldAnalyzer(collectionField, language string) (string, error) {
+	im, ok := ti.indexMapping.(*mapping.IndexMappingImpl)
+	if !ok {
+		return "", fmt.Errorf("unexpected index mapping %T", ti.indexMapping)
+	}
+
+	col, field, found := strings.Cut(collectionField, "/")
+	if !found {
+		return "", fmt.Errorf("invalid collection field %q", collectionField)
+	}
+
+	language = cmp.Or(language, ti.organizationLanguage, defaultLanguage)
+	if supportedLanguage(language, "") == "" {
+		return "", fmt.Errorf("language %q is not supported", language)
+	}
+
+	docMapping, ok := im.TypeMapping[typeName(col, language)]
+	if !ok {
+		return "", fmt.Errorf("collection %q is not indexed", col)
+	}
+
+	fieldMapping, ok := docMapping.Properties[field]
+	if !ok || len(fieldMapping.Fields) == 0 {
+		return "", fmt.Errorf("field %q is not indexed", collectionField)
+	}
+
+	fm := fieldMapping.Fields[0]
+	if fm.Type != "text" {
+		return "", fmt.Errorf("field %q is not a text field", collectionField)
+	}
+	if fm.Analyzer != "" {
+		return fm.Analyzer, nil
+	}
+	if docMapping.DefaultAnalyzer != "" {
+		return docMapping.DefaultAnalyzer, nil
+	}
+	return im.DefaultAnalyzer, nil
+}
+
+// Analyze runs the named analyzer on the text.
+func (ti *TextIndex) Analyze(analyzer, text string) ([]Token, error) {
+	im, ok := ti.indexMapping.(*mapping.IndexMappingImpl)
+	if !ok {
+		return nil, fmt.Errorf("unexpected index mapping %T", ti.indexMapping)
+	}
+
+	stream, err := im.AnalyzeText(analyzer, []byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("analyzer %q: %w", analyzer, err)
+	}
+
+	tokens := make([]Token, len(stream))
+	for i, token := range stream {
+		tokens[i] = Token{
+			Term:     string(token.Term),
+			Start:    token.Start,
+			End:      token.End,
+			Position: token.Position,
+			Type:     tokenTypes[token.Type],
+		}
+	}
+	return tokens, nil
+}
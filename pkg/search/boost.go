@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	index "github.com/blevesearch/bleve_index_api"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+// SetBoosts replaces the boosts used by the following searches.
+func (ti *TextIndex) SetBoosts(boosts *meta.Boosts) {
+	ti.boosts.Store(boosts)
+}
+
+// fieldBoostQuery returns a query adding the weight of matches within the
+// boosted fields analyzed by the given analyzer or the one configured for
+// the field. A boost of b counts a match within the field b times.
+// Returns nil if no field is boosted.
+func (ti *TextIndex) fieldBoostQuery(question string, collections []string, boosts *meta.Boosts, analyzer string) query.Query {
+	var queries []query.Query
+	for col, fields := range boosts.Fields {
+		if len(collections) > 0 && !slices.Contains(collections, col) {
+			continue
+		}
+		for field, boost := range fields {
+			if boost <= 1 {
+				continue
+			}
+			if c, ok := ti.collections[col]; !ok || c.Fields[field] == nil || !c.Fields[field].Searchable {
+				continue
+			}
+
+			matchQuery := bleve.NewMatchQuery(question)
+			matchQuery.SetField(field)
+			matchQuery.SetBoost(boost - 1)
+			matchQuery.Analyzer = analyzer
+			if a := ti.collections[col].Fields[field].Analyzer; a != nil && *a != "html" {
+				matchQuery.Analyzer = *a
+			}
+
+			colQuery := bleve.NewTermQuery(col)
+			colQuery.SetField("_bleve_type")
+
+			bq := bleve.NewBooleanQuery()
+			bq.AddMust(matchQuery)
+			bq.AddFilter(colQuery)
+			queries = append(queries, bq)
+		}
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+// collectionBoostQuery multiplies the scores of the objects of the boosted
+// collections.
+type collectionBoostQuery struct {
+	boosts map[string]float64
+	query  query.Query
+}
+
+func (q *collectionBoostQuery) Searcher(
+	ctx context.Context,
+	i index.IndexReader,
+	m mapping.IndexMapping,
+	options bsearch.SearcherOptions,
+) (bsearch.Searcher, error) {
+	s, err := q.query.Searcher(ctx, i, m, options)
+	if err != nil {
+		return nil, err
+	}
+
+	dvReader, err := i.DocValueReader([]string{"_bleve_type"})
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return &collectionBoostSearcher{
+		Searcher: s,
+		boosts:   q.boosts,
+		dvReader: dvReader,
+		explain:  options.Explain,
+	}, nil
+}
+
+type collectionBoostSearcher struct {
+	bsearch.Searcher
+	boosts   map[string]float64
+	dvReader index.DocValueReader
+	explain  bool
+}
+
+func (s *collectionBoostSearcher) boost(dm *bsearch.DocumentMatch, err error) (*bsearch.DocumentMatch, error) {
+	if dm == nil || err != nil {
+		return dm, err
+	}
+
+	var col string
+	if err := s.dvReader.VisitDocValues(dm.IndexInternalID, func(_ string, term []byte) {
+		col = string(term)
+	}); err != nil {
+		return nil, err
+	}
+
+	boost, ok := s.boosts[col]
+	if !ok {
+		return dm, nil
+	}
+
+	dm.Score *= boost
+	if s.explain && dm.Expl != nil {
+		dm.Expl = &bsearch.Explanation{
+			Value:   dm.Score,
+			Message: "product of:",
+			Children: []*bsearch.Explanation{
+				dm.Expl,
+				{Value: boost, Message: fmt.Sprintf("collection boost(%s)", col)},
+			},
+		}
+	}
+	return dm, nil
+}
+
+func (s *collectionBoostSearcher) Next(ctx *bsearch.SearchContext) (*bsearch.DocumentMatch, error) {
+	return s.boost(s.Searcher.Next(ctx))
+}
+
+func (s *collectionBoostSearcher) Advance(ctx *bsearch.SearchContext, id index.IndexInternalID) (*bsearch.DocumentMatch, error) {
+	return s.boost(s.Searcher.Advance(ctx, id))
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/mapping"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+// collapseField holds the fqid of the object the hits of a document are
+// collapsed into, e.g. the content object of an agenda item.
+const collapseField = "_collapse"
+
+// CollapsedHit is a hit folded into the answer of another object.
+type CollapsedHit struct {
+	FQID         string              `json:"fqid"`
+	Score        float64             `json:"score"`
+	MatchedWords map[string][]string `json:"matched_by"`
+}
+
+// fillCollapse copies the fqid the document is collapsed into.
+func (bt bleveType) fillCollapse(fields map[string]*meta.Member) {
+	for fname, field := range fields {
+		if fqid, ok := bt[fname].(string); ok && field.CollapseInto && strings.Contains(fqid, "/") {
+			bt[collapseField] = fqid
+			return
+		}
+	}
+}
+
+// addCollapseFieldMapping stores the fqid the document is collapsed into.
+func addCollapseFieldMapping(docMapping *mapping.DocumentMapping, fields map[string]*meta.Member) {
+	fm := bleve.NewTextFieldMapping()
+	fm.Analyzer = keyword.Name
+	fm.Store = true
+	fm.IncludeInAll = false
+	fm.IncludeTermVectors = false
+	docMapping.AddFieldMappingsAt(collapseField, fm)
+
+	for fname, field := range fields {
+		if field.CollapseInto && !field.Searchable && len(field.Scopes) == 0 && !field.Date && !field.Sortable {
+			// Only the copy is needed.
+			docMapping.AddFieldMappingsAt(fname, &mapping.FieldMapping{Type: "text", Index: false})
+		}
+	}
+}
+
+// collapse folds the answers of auxiliary objects into the answers of the
+// objects they belong to. Scores are summed up and the folded hits are
+// recorded. Objects of collections not searched are not collapsed into.
+// Without sorting by fields the answers are ranked by their new scores.
+func (ti *TextIndex) collapse(answers map[string]Answer, result *bleve.SearchResult, collections []string, sorted bool) map[string]Answer {
+	targets := map[string]string{}
+	for _, hit := range result.Hits {
+		target, ok := hit.Fields[collapseField].(string)
+		if !ok || target == hit.ID {
+			continue
+		}
+		col, _, _ := strings.Cut(target, "/")
+		if _, ok := ti.collections[col]; !ok {
+			continue
+		}
+		if len(collections) > 0 && !slices.Contains(collections, col) {
+			continue
+		}
+		targets[hit.ID] = target
+	}
+	if len(targets) == 0 {
+		return answers
+	}
+
+	collapsed := make(map[string]Answer, len(answers))
+	for fqid, answer := range answers {
+		if _, ok := targets[fqid]; !ok {
+			collapsed[fqid] = answer
+		}
+	}
+	for _, fqid := range slices.Sorted(maps.Keys(targets)) {
+		answer := answers[fqid]
+		primary, ok := collapsed[targets[fqid]]
+		if !ok {
+			// The object itself did not match.
+			primary = Answer{Rank: answer.Rank, MatchedWords: map[string][]string{}}
+		}
+		primary.Score += answer.Score
+		primary.Rank = min(primary.Rank, answer.Rank)
+		primary.Contributions = mergeContributions(primary.Contributions, answer.Contributions)
+		primary.Collapsed = append(primary.Collapsed, CollapsedHit{
+			FQID:         fqid,
+			Score:        answer.Score,
+			MatchedWords: answer.MatchedWords,
+		})
+		collapsed[targets[fqid]] = primary
+	}
+
+	fqids := slices.Collect(maps.Keys(collapsed))
+	slices.SortFunc(fqids, func(a, b string) int {
+		if !sorted {
+			if c := cmp.Compare(collapsed[b].Score, collapsed[a].Score); c != 0 {
+				return c
+			}
+		}
+		if c := cmp.Compare(collapsed[a].Rank, collapsed[b].Rank); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	for i, fqid := range fqids {
+		answer := collapsed[fqid]
+		answer.Rank = i + 1
+		slices.SortFunc(answer.Collapsed, func(a, b CollapsedHit) int {
+			return cmp.Or(cmp.Compare(b.Score, a.Score), strings.Compare(a.FQID, b.FQID))
+		})
+		collapsed[fqid] = answer
+	}
+	return collapsed
+}
+
+// mergeContributions sums up the contributions of two answers.
+func mergeContributions(a, b map[string]float64) map[string]float64 {
+	if len(b) == 0 {
+		return a
+	}
+	merged := maps.Clone(a)
+	if merged == nil {
+		merged = map[string]float64{}
+	}
+	for name, v := range b {
+		merged[name] += v
+	}
+	return merged
+}
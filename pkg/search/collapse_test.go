@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestCollapse(t *testing.T) {
+	collections := meta.Collections{
+		"topic": {Fields: map[string]*meta.Member{
+			"title": {Type: "string", Searchable: true},
+		}},
+		"agenda_item": {Fields: map[string]*meta.Member{
+			"comment":           {Type: "string", Searchable: true},
+			"content_object_id": {Type: "generic-relation", CollapseInto: true},
+		}},
+	}
+
+	ti := newTestIndex(t, collections, map[string]map[string]any{
+		"topic/1":       {"title": "Budget"},
+		"topic/2":       {"title": "Elections"},
+		"topic/3":       {"title": "Budget"},
+		"agenda_item/1": {"comment": "Budget", "content_object_id": "topic/1"},
+		"agenda_item/2": {"comment": "Budget", "content_object_id": "topic/2"},
+	})
+
+	separate, err := ti.Search(context.Background(), "budget", nil, nil, Options{})
+	if err != nil {
+		t.Fatalf("searching failed: %v", err)
+	}
+	if len(separate) != 4 {
+		t.Errorf("found %d answers without collapsing, expected 4", len(separate))
+	}
+
+	answers, err := ti.Search(context.Background(), "budget", nil, nil, Options{Collapse: true})
+	if err != nil {
+		t.Fatalf("searching failed: %v", err)
+	}
+
+	var got []string
+	for fqid := range answers {
+		got = append(got, fqid)
+	}
+	slices.Sort(got)
+	if expect := []string{"topic/1", "topic/2", "topic/3"}; !slices.Equal(got, expect) {
+		t.Fatalf("found %v, expected %v", got, expect)
+	}
+
+	merged := answers["topic/1"]
+	if len(merged.Collapsed) != 1 || merged.Collapsed[0].FQID != "agenda_item/1" {
+		t.Errorf("topic/1 collapsed %v, expected agenda_item/1", merged.Collapsed)
+	}
+	expectScore := separate["topic/1"].Score + separate["agenda_item/1"].Score
+	if math.Abs(merged.Score-expectScore) > 1e-9 {
+		t.Errorf("topic/1 has score %f, expected %f", merged.Score, expectScore)
+	}
+	if merged.Rank != 1 {
+		t.Errorf("topic/1 has rank %d, expected 1", merged.Rank)
+	}
+
+	// The topic itself does not match but its agenda item does.
+	if only := answers["topic/2"]; len(only.MatchedWords) != 0 || len(only.Collapsed) != 1 {
+		t.Errorf("topic/2 got %v, expected only the collapsed agenda item", only)
+	}
+
+	// Hits are not collapsed into collections which are not searched.
+	answers, err = ti.Search(context.Background(), "budget", []string{"agenda_item"}, nil, Options{Collapse: true})
+	if err != nil {
+		t.Fatalf("searching failed: %v", err)
+	}
+	if len(answers) != 2 || answers["agenda_item/1"].Collapsed != nil {
+		t.Errorf("searching agenda items found %v, expected them uncollapsed", answers)
+	}
+}
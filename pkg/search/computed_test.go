@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestComputedValue(t *testing.T) {
+	for _, tt := range []struct {
+		template string
+		data     map[string]any
+		expect   any
+	}{
+		{"{first_name} {last_name}", map[string]any{"first_name": "Max", "last_name": "Mustermann"}, "Max Mustermann"},
+		{"{first_name} {last_name}", map[string]any{"last_name": "Mustermann"}, "Mustermann"},
+		{"{first_name} {last_name}", map[string]any{}, nil},
+		{"{number}: {title}", map[string]any{"number": "A1", "title": "Haushalt"}, "A1: Haushalt"},
+		{"{number}: {title}", map[string]any{"number": "", "title": "Haushalt"}, "Haushalt"},
+		{"TOP {item_number}", map[string]any{"item_number": int32(3)}, "TOP 3"},
+		{"{name} ({levels})", map[string]any{"name": "Max", "levels": []string{"Berlin", "Hamburg"}}, "Max (Berlin Hamburg)"},
+		{"{name} ({levels})", map[string]any{"levels": []string{"Berlin", "Hamburg"}}, "Berlin Hamburg"},
+		{"{name} ({levels})", map[string]any{"name": "Max"}, "Max"},
+		{"({levels})", map[string]any{"levels": []string{"Berlin"}}, "(Berlin)"},
+	} {
+		template, err := meta.ParseTemplate(tt.template)
+		if err != nil {
+			t.Fatalf("parsing %q failed: %v", tt.template, err)
+		}
+		if got := computeValue(template, tt.data); got != tt.expect {
+			t.Errorf("%q with %v = %v, expected %v", tt.template, tt.data, got, tt.expect)
+		}
+	}
+
+	for _, invalid := range []string{"no fields", "{open", "close}", "{}", "{a{b}}"} {
+		if _, err := meta.ParseTemplate(invalid); err == nil {
+			t.Errorf("parsing %q did not fail", invalid)
+		}
+	}
+}
+
+func TestComputedFields(t *testing.T) {
+	collections := meta.Collections{
+		"user": {Fields: map[string]*meta.Member{
+			"first_name": {Type: "string", Searchable: true},
+			"last_name":  {Type: "string", Searchable: true},
+		}},
+	}
+	filters := meta.Filters{{
+		Name:     "user",
+		Computed: map[string]string{"full_name": "{first_name} {last_name}"},
+	}}
+	if err := collections.AddComputed(filters); err != nil {
+		t.Fatalf("adding computed fields failed: %v", err)
+	}
+
+	ti := newTestIndex(t, collections, map[string]map[string]any{
+		"user/1": {"first_name": "Max", "last_name": "Mustermann"},
+		"user/2": {"first_name": "Erika", "last_name": "Max"},
+	})
+
+	q := bleve.NewMatchPhraseQuery("Max Mustermann")
+	q.SetField("full_name")
+	q.Analyzer = textAnalyzer(defaultLanguage)
+	if got := matchingDocs(t, ti, q); !slices.Equal(got, []string{"user/1"}) {
+		t.Errorf("phrase found %v, expected user/1", got)
+	}
+
+	// Updates compute the field again.
+	indexTestDocs(t, ti, map[string]map[string]any{
+		"user/1": {"first_name": "Moritz", "last_name": "Mustermann"},
+	})
+	if got := matchingDocs(t, ti, q); len(got) != 0 {
+		t.Errorf("phrase found %v after update, expected nothing", got)
+	}
+}
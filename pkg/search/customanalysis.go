@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/simple"
+	"github.com/blevesearch/bleve/v2/analysis/token/ngram"
+	"github.com/blevesearch/bleve/v2/mapping"
+
+	// Components available to the custom analyzers next to the ones of
+	// the built in analyzers.
+	_ "github.com/blevesearch/bleve/v2/analysis/char/asciifolding"
+	_ "github.com/blevesearch/bleve/v2/analysis/char/regexp"
+	_ "github.com/blevesearch/bleve/v2/analysis/char/zerowidthnonjoiner"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/apostrophe"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/camelcase"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/edgengram"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/elision"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/length"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/porter"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/reverse"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/shingle"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/stop"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/truncate"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/unicodenorm"
+	_ "github.com/blevesearch/bleve/v2/analysis/token/unique"
+	_ "github.com/blevesearch/bleve/v2/analysis/tokenizer/exception"
+	_ "github.com/blevesearch/bleve/v2/analysis/tokenizer/regexp"
+	_ "github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
+	_ "github.com/blevesearch/bleve/v2/analysis/tokenizer/whitespace"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+// ngramFilter is the token filter of the n-gram variants of the custom
+// analyzers.
+const ngramFilter = "_ngram"
+
+// addCustomAnalysis defines the custom analyzers and their components in
+// the index mapping. Every analyzer gets an n-gram variant for the
+// substring search. Invalid definitions are reported as error.
+func addCustomAnalysis(im *mapping.IndexMappingImpl, analysis *meta.Analysis) error {
+	if analysis == nil || len(analysis.Analyzers) == 0 {
+		return nil
+	}
+
+	for _, component := range []struct {
+		kind    string
+		configs map[string]map[string]any
+		add     func(string, map[string]any) error
+	}{
+		{"char filter", analysis.CharFilters, im.AddCustomCharFilter},
+		{"tokenizer", analysis.Tokenizers, im.AddCustomTokenizer},
+		{"token map", analysis.TokenMaps, im.AddCustomTokenMap},
+		{"token filter", analysis.TokenFilters, im.AddCustomTokenFilter},
+	} {
+		// Sorted to report errors deterministically.
+		for _, name := range slices.Sorted(maps.Keys(component.configs)) {
+			if err := component.add(name, component.configs[name]); err != nil {
+				return fmt.Errorf("%s %s: %w", component.kind, name, err)
+			}
+		}
+	}
+
+	if err := im.AddCustomTokenFilter(ngramFilter, map[string]any{
+		"type": ngram.Name,
+		"min":  float64(ngramMin),
+		"max":  float64(ngramMax),
+	}); err != nil {
+		return fmt.Errorf("token filter %s: %w", ngramFilter, err)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(analysis.Analyzers)) {
+		if name == "html" || name == simple.Name || name == identifierAnalyzer || name == sortAnalyzer {
+			return fmt.Errorf("analyzer %s: name is reserved", name)
+		}
+
+		analyzer := analysis.Analyzers[name]
+		config := map[string]any{
+			"type":          custom.Name,
+			"char_filters":  toAny(analyzer.CharFilters),
+			"tokenizer":     analyzer.Tokenizer,
+			"token_filters": toAny(analyzer.TokenFilters),
+		}
+		if err := im.AddCustomAnalyzer(name, config); err != nil {
+			return fmt.Errorf("analyzer %s: %w", name, err)
+		}
+
+		config["token_filters"] = append(toAny(analyzer.TokenFilters), ngramFilter)
+		if err := im.AddCustomAnalyzer(ngramAnalyzer(name), config); err != nil {
+			return fmt.Errorf("analyzer %s: %w", ngramAnalyzer(name), err)
+		}
+	}
+	return nil
+}
+
+// toAny converts the names like they are read from the JSON index mapping.
+func toAny(names []string) []any {
+	rv := make([]any, len(names))
+	for i, name := range names {
+		rv[i] = name
+	}
+	return rv
+}
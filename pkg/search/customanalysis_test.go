@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/goccy/go-yaml"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+const customAnalysisYml = `
+_analysis:
+  token_maps:
+    motion_stop:
+      type: custom
+      tokens: [antrag, der]
+  token_filters:
+    motion_stop:
+      type: stop_tokens
+      stop_token_map: motion_stop
+    short:
+      type: length
+      min: 2
+      max: 10
+  analyzers:
+    motion_title:
+      char_filters: [asciifolding]
+      tokenizer: unicode
+      token_filters: [to_lower, motion_stop, short, stemmer_en_snowball]
+motion:
+  searchable: [title]
+  searchable_config:
+    title:
+      analyzer: motion_title
+`
+
+func TestCustomAnalysis(t *testing.T) {
+	var analysis meta.Analysis
+	if err := yaml.Unmarshal([]byte(customAnalysisYml), &analysis); err != nil {
+		t.Fatalf("parsing analysis failed: %v", err)
+	}
+
+	var filters meta.Filters
+	if err := yaml.Unmarshal([]byte(customAnalysisYml), &filters); err != nil {
+		t.Fatalf("parsing filters failed: %v", err)
+	}
+	if len(filters) != 1 || filters[0].Name != "motion" {
+		t.Fatalf("analysis is read as filter: %v", filters)
+	}
+
+	analyzer := "motion_title"
+	collections := meta.Collections{
+		"motion": {Fields: map[string]*meta.Member{
+			"title": {Type: "string", Searchable: true, Analyzer: &analyzer},
+		}},
+	}
+
+	im, err := buildIndexMapping(collections, nil, &analysis)
+	if err != nil {
+		t.Fatalf("building mapping failed: %v", err)
+	}
+
+	stream, err := im.(*mapping.IndexMappingImpl).AnalyzeText(analyzer, []byte("Der Antrag über Meetings verabschiedungsfähig"))
+	if err != nil {
+		t.Fatalf("analyzing failed: %v", err)
+	}
+	var terms []string
+	for _, token := range stream {
+		terms = append(terms, string(token.Term))
+	}
+	if expect := []string{"uber", "meet"}; !slices.Equal(terms, expect) {
+		t.Errorf("got terms %v, expected %v", terms, expect)
+	}
+
+	ti := newTestIndexWithMapping(t, im, collections, map[string]map[string]any{
+		"motion/1": {"title": "Vertagung"},
+	})
+	if got := matchingDocs(t, ti, ti.ngramQuery([]string{"tagu"})); !slices.Equal(got, []string{"motion/1"}) {
+		t.Errorf("substring search found %v", got)
+	}
+
+	if _, err := buildIndexMapping(collections, nil, nil); err == nil {
+		t.Errorf("unknown analyzer is accepted")
+	}
+
+	analysis.Analyzers[analyzer].TokenFilters = []string{"unknown"}
+	if _, err := buildIndexMapping(collections, nil, &analysis); err == nil {
+		t.Errorf("unknown token filter is accepted")
+	}
+}
@@ -6,6 +6,7 @@ package search
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -14,6 +15,8 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/OpenSlides/openslides-search-service/pkg/config"
+	"github.com/OpenSlides/openslides-search-service/pkg/metrics"
+	"github.com/OpenSlides/openslides-search-service/pkg/tracing"
 	"github.com/jackc/pgx/v5"
 )
 
@@ -37,7 +40,8 @@ FROM
 	selectLatestUpdates = `
 SELECT DISTINCT ON (fqid)
 	fqid,
-	operation
+	operation,
+	timestamp
 FROM
 	os_notify_log_t
 WHERE
@@ -45,6 +49,50 @@ WHERE
 ORDER BY fqid, id DESC
 	`
 
+	selectMeetingStates = `
+SELECT
+	id,
+	is_active_in_organization_id,
+	template_for_organization_id
+FROM
+	meeting_t
+`
+
+	selectMeetingLanguages = `
+SELECT
+	id,
+	language
+FROM
+	meeting_t
+`
+
+	selectOrganizationLanguage = `
+SELECT
+	default_language
+FROM
+	organization_t
+WHERE
+	id = 1
+`
+
+	selectOrganizationManagementLevel = `
+SELECT
+	organization_management_level
+FROM
+	user_t
+WHERE
+	id = $1
+`
+
+	selectElementsFromTableTemplate = `
+SELECT
+	*
+FROM
+	%s_t
+WHERE
+	id IN (%s)
+`
+
 	selectElementFromTableTemplate = `
 SELECT
 	*
@@ -65,6 +113,8 @@ type Database struct {
 	cfg  *config.Config
 	last time.Time
 	gen  uint16
+	// lag is the age of the oldest change processed by the last update.
+	lag time.Duration
 }
 
 // NewDatabase creates a new database,
@@ -74,8 +124,7 @@ func NewDatabase(cfg *config.Config) *Database {
 	}
 }
 
-func (db *Database) run(fn func(context.Context, *pgx.Conn) error) error {
-	ctx := context.Background()
+func (db *Database) run(ctx context.Context, fn func(context.Context, *pgx.Conn) error) error {
 	config, err := pgx.ParseConfig(db.cfg.Database.ConnectionConfig())
 	if err != nil {
 		return err
@@ -83,6 +132,7 @@ func (db *Database) run(fn func(context.Context, *pgx.Conn) error) error {
 
 	// Simple protocol is used for PGBouncer compatibility
 	config.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	config.Tracer = tracing.PgxTracer{}
 
 	con, err := pgx.ConnectConfig(ctx, config)
 	if err != nil {
@@ -116,7 +166,7 @@ type eventHandler func(evtType updateEventType, collection string, id int, data
 
 func nullEventHandler(updateEventType, string, int, map[string]any) error { return nil }
 
-func (db *Database) update(handler eventHandler) error {
+func (db *Database) update(ctx context.Context, handler eventHandler) error {
 	start := time.Now()
 
 	// Do not update if it is young enough.
@@ -132,7 +182,7 @@ func (db *Database) update(handler eventHandler) error {
 		log.Debugf("updating database took %v\n", time.Since(start))
 	}()
 
-	return db.run(func(ctx context.Context, conn *pgx.Conn) error {
+	return db.run(ctx, func(ctx context.Context, conn *pgx.Conn) error {
 
 		updateLogs, err := conn.Query(ctx, selectLatestUpdates, db.last)
 		if err != nil {
@@ -145,16 +195,22 @@ func (db *Database) update(handler eventHandler) error {
 		ngen := db.gen + 1 // may overflow but thats okay.
 
 		changeMap := make(map[string][]updateOperation)
+		var oldest time.Time
 
 		// Convert each fqid to a tablename - id mapping
 		for updateLogs.Next() {
 			var fqid string
 			var operation string
-			err = updateLogs.Scan(&fqid, &operation)
+			var timestamp time.Time
+			err = updateLogs.Scan(&fqid, &operation, &timestamp)
 			if err != nil {
 				return err
 			}
 
+			if oldest.IsZero() || timestamp.Before(oldest) {
+				oldest = timestamp
+			}
+
 			tableName, id, err := splitFqid(fqid)
 
 			if err != nil {
@@ -264,6 +320,12 @@ func (db *Database) update(handler eventHandler) error {
 		log.Debugf("added: %d / removed: %d\n",
 			added, removed)
 
+		db.lag = 0
+		if !oldest.IsZero() {
+			db.lag = time.Since(oldest)
+			metrics.IndexUpdateLag.Observe(db.lag.Seconds())
+		}
+
 		db.last = start
 		db.gen = ngen
 		return nil
@@ -296,7 +358,7 @@ func (db *Database) generateTableQueryMap(ctx context.Context, conn *pgx.Conn) (
 	return queryMap, nil
 }
 
-func (db *Database) fill(handler eventHandler) error {
+func (db *Database) fill(ctx context.Context, handler eventHandler) error {
 	start := time.Now()
 	defer func() {
 		log.Infof("initial database fill took %v\n", time.Since(start))
@@ -306,7 +368,7 @@ func (db *Database) fill(handler eventHandler) error {
 		handler = nullEventHandler
 	}
 
-	return db.run(func(ctx context.Context, conn *pgx.Conn) error {
+	return db.run(ctx, func(ctx context.Context, conn *pgx.Conn) error {
 		queryMap, err := db.generateTableQueryMap(ctx, conn)
 		if err != nil {
 			return err
@@ -385,3 +447,159 @@ func (db *Database) fill(handler eventHandler) error {
 		return nil
 	})
 }
+
+// Ping checks if the database is reachable.
+func (db *Database) Ping(ctx context.Context) error {
+	return db.run(ctx, func(ctx context.Context, conn *pgx.Conn) error {
+		return conn.Ping(ctx)
+	})
+}
+
+// IsSuperadmin checks if the given user is a superadmin of the organization.
+func (db *Database) IsSuperadmin(ctx context.Context, userID int) (bool, error) {
+	var level *string
+	if err := db.run(ctx, func(ctx context.Context, conn *pgx.Conn) error {
+		return conn.QueryRow(ctx, selectOrganizationManagementLevel, userID).Scan(&level)
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return level != nil && *level == "superadmin", nil
+}
+
+// meetingStates returns the lifecycle states of all meetings.
+func (db *Database) meetingStates(ctx context.Context) (map[int]string, error) {
+	states := map[int]string{}
+	if err := db.run(ctx, func(ctx context.Context, conn *pgx.Conn) error {
+		rows, err := conn.Query(ctx, selectMeetingStates)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int32
+			var active, template *int32
+			if err := rows.Scan(&id, &active, &template); err != nil {
+				return err
+			}
+			states[int(id)] = meetingState(active != nil, template != nil)
+		}
+		return rows.Err()
+	}); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// meetingLanguages returns the supported languages of all meetings.
+// Meetings with an unsupported language are left out.
+func (db *Database) meetingLanguages(ctx context.Context) (map[int]string, error) {
+	languages := map[int]string{}
+	if err := db.run(ctx, func(ctx context.Context, conn *pgx.Conn) error {
+		rows, err := conn.Query(ctx, selectMeetingLanguages)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int32
+			var language *string
+			if err := rows.Scan(&id, &language); err != nil {
+				return err
+			}
+			if language != nil {
+				if l := supportedLanguage(*language, ""); l != "" {
+					languages[int(id)] = l
+				}
+			}
+		}
+		return rows.Err()
+	}); err != nil {
+		return nil, err
+	}
+	return languages, nil
+}
+
+// organizationLanguage returns the default language of the organization.
+// It falls back to the default language if it is not supported.
+func (db *Database) organizationLanguage(ctx context.Context) (string, error) {
+	var language *string
+	if err := db.run(ctx, func(ctx context.Context, conn *pgx.Conn) error {
+		return conn.QueryRow(ctx, selectOrganizationLanguage).Scan(&language)
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return defaultLanguage, nil
+		}
+		return "", err
+	}
+	if language == nil {
+		return defaultLanguage, nil
+	}
+	return supportedLanguage(*language, defaultLanguage), nil
+}
+
+// load calls the handler with the current rows of the given fqids.
+// Rows which do not exist anymore are skipped.
+func (db *Database) load(ctx context.Context, fqids []string, handler eventHandler) error {
+	tables := map[string][]string{}
+	for _, fqid := range fqids {
+		col, id, err := splitFqid(fqid)
+		if err != nil {
+			return err
+		}
+		tables[col] = append(tables[col], strconv.Itoa(id))
+	}
+
+	return db.run(ctx, func(ctx context.Context, conn *pgx.Conn) error {
+		for col, ids := range tables {
+			query := fmt.Sprintf(selectElementsFromTableTemplate, col, strings.Join(ids, ","))
+			rows, err := conn.Query(ctx, query)
+			if err != nil {
+				return err
+			}
+
+			descriptions := rows.FieldDescriptions()
+			columns := make([]string, len(descriptions))
+			for i, description := range descriptions {
+				columns[i] = description.Name
+			}
+
+			for rows.Next() {
+				values, err := rows.Values()
+				if err != nil {
+					rows.Close()
+					return err
+				}
+
+				data := make(map[string]any, len(values))
+				id := int32(-1)
+				for i, v := range values {
+					if columns[i] == "id" {
+						if val, ok := v.(int32); ok {
+							id = val
+						}
+						continue
+					}
+					data[columns[i]] = v
+				}
+				if id == -1 {
+					continue
+				}
+
+				if err := handler(changedEvent, col, int(id), data); err != nil {
+					rows.Close()
+					return err
+				}
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/numeric"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	index "github.com/blevesearch/bleve_index_api"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+// dateField holds the timestamps of all date fields of a document. It is
+// filtered by the time range of a search and used for the recency boost.
+const dateField = "_date"
+
+// fillDates collects the timestamps of the date fields.
+func (bt bleveType) fillDates(fields map[string]*meta.Member) {
+	var dates []time.Time
+	for fname, field := range fields {
+		if t, ok := bt[fname].(time.Time); ok && field.Date {
+			dates = append(dates, t)
+		}
+	}
+	if len(dates) > 0 {
+		bt[dateField] = dates
+	}
+}
+
+// addDateFieldMappings maps the date fields which are not searchable and
+// the collected timestamps.
+func addDateFieldMappings(docMapping *mapping.DocumentMapping, fields map[string]*meta.Member) {
+	fm := bleve.NewDateTimeFieldMapping()
+	fm.Store = false
+	fm.IncludeInAll = false
+	for fname, field := range fields {
+		if field.Date && !field.Searchable && len(field.Scopes) == 0 {
+			docMapping.AddFieldMappingsAt(fname, fm)
+		}
+	}
+	docMapping.AddFieldMappingsAt(dateField, fm)
+}
+
+// dateRangeQuery matches documents with a timestamp between from and to.
+// Zero times leave the range open. Returns nil if both are zero.
+func dateRangeQuery(from, to time.Time) query.Query {
+	if from.IsZero() && to.IsZero() {
+		return nil
+	}
+	inclusive := true
+	q := bleve.NewDateRangeInclusiveQuery(from, to, &inclusive, &inclusive)
+	q.SetField(dateField)
+	return q
+}
+
+// recencyBoostQuery ranks recent objects higher. The score of an object is
+// multiplied by 1 + 0.5^(age/halfLife) of its newest timestamp, so it is
+// at most doubled. Objects without timestamp keep their score.
+type recencyBoostQuery struct {
+	halfLife time.Duration
+	now      time.Time
+	query    query.Query
+}
+
+func (q *recencyBoostQuery) Searcher(
+	ctx context.Context,
+	i index.IndexReader,
+	m mapping.IndexMapping,
+	options bsearch.SearcherOptions,
+) (bsearch.Searcher, error) {
+	s, err := q.query.Searcher(ctx, i, m, options)
+	if err != nil {
+		return nil, err
+	}
+
+	dvReader, err := i.DocValueReader([]string{dateField})
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return &recencyBoostSearcher{
+		Searcher: s,
+		halfLife: q.halfLife,
+		now:      q.now,
+		dvReader: dvReader,
+		explain:  options.Explain,
+	}, nil
+}
+
+type recencyBoostSearcher struct {
+	bsearch.Searcher
+	halfLife time.Duration
+	now      time.Time
+	dvReader index.DocValueReader
+	explain  bool
+}
+
+func (s *recencyBoostSearcher) boost(dm *bsearch.DocumentMatch, err error) (*bsearch.DocumentMatch, error) {
+	if dm == nil || err != nil {
+		return dm, err
+	}
+
+	var newest int64
+	found := false
+	if err := s.dvReader.VisitDocValues(dm.IndexInternalID, func(_ string, term []byte) {
+		// Timestamps are indexed with several precisions. Only the
+		// exact one is of interest.
+		coded := numeric.PrefixCoded(term)
+		if shift, err := coded.Shift(); err != nil || shift != 0 {
+			return
+		}
+		if nanos, err := coded.Int64(); err == nil && (!found || nanos > newest) {
+			newest, found = nanos, true
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if !found {
+		return dm, nil
+	}
+
+	age := max(s.now.Sub(time.Unix(0, newest)), 0)
+	boost := 1 + math.Pow(0.5, float64(age)/float64(s.halfLife))
+
+	dm.Score *= boost
+	if s.explain && dm.Expl != nil {
+		dm.Expl = &bsearch.Explanation{
+			Value:   dm.Score,
+			Message: "product of:",
+			Children: []*bsearch.Explanation{
+				dm.Expl,
+				{Value: boost, Message: fmt.Sprintf("recency boost(%s)", time.Unix(0, newest).UTC().Format(time.RFC3339))},
+			},
+		}
+	}
+	return dm, nil
+}
+
+func (s *recencyBoostSearcher) Next(ctx *bsearch.SearchContext) (*bsearch.DocumentMatch, error) {
+	return s.boost(s.Searcher.Next(ctx))
+}
+
+func (s *recencyBoostSearcher) Advance(ctx *bsearch.SearchContext, id index.IndexInternalID) (*bsearch.DocumentMatch, error) {
+	return s.boost(s.Searcher.Advance(ctx, id))
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestDates(t *testing.T) {
+	collections := meta.Collections{
+		"motion": {Fields: map[string]*meta.Member{
+			"title":         {Type: "string", Searchable: true},
+			"created":       {Type: "timestamp", Date: true},
+			"last_modified": {Type: "timestamp", Date: true},
+		}},
+	}
+
+	now := time.Now()
+	ti := newTestIndex(t, collections, map[string]map[string]any{
+		"motion/1": {"title": "Haushalt", "created": now.AddDate(-2, 0, 0)},
+		"motion/2": {"title": "Haushalt", "created": now.AddDate(-1, 0, 0), "last_modified": now.AddDate(0, -1, 0)},
+		"motion/3": {"title": "Haushalt", "created": now.AddDate(0, 0, -7)},
+		"motion/4": {"title": "Haushalt"},
+	})
+
+	search := func(opts Options) []string {
+		t.Helper()
+		request := bleve.NewSearchRequest(ti.buildQuery("Haushalt", nil, nil, opts))
+		result, err := ti.index.SearchInContext(context.Background(), request)
+		if err != nil {
+			t.Fatalf("searching failed: %v", err)
+		}
+		ids := make([]string, len(result.Hits))
+		for i, hit := range result.Hits {
+			ids[i] = hit.ID
+		}
+		return ids
+	}
+
+	for _, tt := range []struct {
+		name   string
+		opts   Options
+		expect []string
+	}{
+		{"from", Options{From: now.AddDate(0, -2, 0)}, []string{"motion/2", "motion/3"}},
+		{"to", Options{To: now.AddDate(0, -18, 0)}, []string{"motion/1"}},
+		{"range", Options{From: now.AddDate(0, -13, 0), To: now.AddDate(0, -11, 0)}, []string{"motion/2"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := search(tt.opts)
+			slices.Sort(got)
+			if !slices.Equal(got, tt.expect) {
+				t.Errorf("got %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+
+	got := search(Options{Recency: 30 * 24 * time.Hour})
+	expect := []string{"motion/3", "motion/2", "motion/1", "motion/4"}
+	if !slices.Equal(got, expect) {
+		t.Errorf("ranked by recency %v, expected %v", got, expect)
+	}
+}
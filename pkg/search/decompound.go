@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/analysis/token/compound"
+	"github.com/blevesearch/bleve/v2/analysis/tokenmap"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/registry"
+)
+
+const (
+	// deDecompound is the token filter splitting German compound words.
+	deDecompound = "de_decompound"
+	// deDecompoundWords is the token map of the compound parts.
+	deDecompoundWords = "de_decompound_words"
+	// decompoundFilterType is the type of the decompound token filter.
+	decompoundFilterType = "decompound"
+)
+
+// Limits of the decompounding. Words shorter than decompoundMinWordSize
+// are not split and parts have to be at least decompoundMinPartSize long.
+const (
+	decompoundMinWordSize = 6
+	decompoundMinPartSize = 4
+	decompoundMaxPartSize = 20
+)
+
+//go:embed decompound_de.txt
+var defaultDecompoundWords []byte
+
+// decompoundWords returns the bundled compound parts together with the ones
+// of the given file.
+func decompoundWords(file string) ([]any, error) {
+	words := analysis.NewTokenMap()
+	if err := words.LoadBytes(defaultDecompoundWords); err != nil {
+		return nil, fmt.Errorf("loading default decompound words failed: %w", err)
+	}
+	if file != "" {
+		if err := words.LoadFile(file); err != nil {
+			return nil, fmt.Errorf("loading decompound words from %q failed: %w", file, err)
+		}
+	}
+
+	tokens := make([]any, 0, len(words))
+	for word := range words {
+		tokens = append(tokens, strings.ToLower(word))
+	}
+	return tokens, nil
+}
+
+// addDecompoundFilter adds the decompound token filter to the mapping.
+func addDecompoundFilter(im *mapping.IndexMappingImpl, words []any) error {
+	if err := im.AddCustomTokenMap(deDecompoundWords, map[string]any{
+		"type":   tokenmap.Name,
+		"tokens": words,
+	}); err != nil {
+		return err
+	}
+	return im.AddCustomTokenFilter(deDecompound, map[string]any{
+		"type":           decompoundFilterType,
+		"dict_token_map": deDecompoundWords,
+	})
+}
+
+// decompoundFilter adds the known parts of compound words as tokens at the
+// position of the compound word.
+type decompoundFilter struct {
+	dict *compound.DictionaryCompoundFilter
+}
+
+func (f *decompoundFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	rv := make(analysis.TokenStream, 0, len(input))
+	for _, token := range input {
+		rv = append(rv, token)
+		for _, part := range f.dict.Filter(analysis.TokenStream{token})[1:] {
+			// A known word is not a part of itself.
+			if string(part.Term) != string(token.Term) {
+				rv = append(rv, part)
+			}
+		}
+	}
+	return rv
+}
+
+func decompoundFilterConstructor(config map[string]any, cache *registry.Cache) (analysis.TokenFilter, error) {
+	name, ok := config["dict_token_map"].(string)
+	if !ok {
+		return nil, fmt.Errorf("must specify dict_token_map")
+	}
+	dict, err := cache.TokenMapNamed(name)
+	if err != nil {
+		return nil, fmt.Errorf("building decompound filter failed: %w", err)
+	}
+	return &decompoundFilter{
+		dict: compound.NewDictionaryCompoundFilter(
+			dict,
+			decompoundMinWordSize,
+			decompoundMinPartSize,
+			decompoundMaxPartSize,
+			true,
+		),
+	}, nil
+}
+
+func init() {
+	registry.RegisterTokenFilter(decompoundFilterType, decompoundFilterConstructor)
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2/mapping"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestDecompound(t *testing.T) {
+	words, err := decompoundWords("")
+	if err != nil {
+		t.Fatalf("loading words failed: %v", err)
+	}
+
+	im, err := buildIndexMapping(meta.Collections{}, words, nil)
+	if err != nil {
+		t.Fatalf("building mapping failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		analyzer string
+		text     string
+		expect   []string
+	}{
+		{deText, "Haushaltsantrag", []string{"haushaltsantrag", "haushalt", "antrag"}},
+		{deText, "Haushalt", []string{"haushalt"}},
+		{deText, "einfach", []string{"einfach"}},
+		{deHTML, "<p>Satzungsänderung</p>", []string{"satzungsanderung", "satzung", "anderung"}},
+	} {
+		t.Run(tt.text, func(t *testing.T) {
+			stream, err := im.(*mapping.IndexMappingImpl).AnalyzeText(tt.analyzer, []byte(tt.text))
+			if err != nil {
+				t.Fatalf("analyzing failed: %v", err)
+			}
+
+			var got []string
+			for _, token := range stream {
+				got = append(got, string(token.Term))
+			}
+			if !slices.Equal(got, tt.expect) {
+				t.Errorf("got %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+}
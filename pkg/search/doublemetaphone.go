@@ -0,0 +1,599 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"strings"
+)
+
+// doubleMetaphoneLength is the maximal length of the codes.
+const doubleMetaphoneLength = 4
+
+// doubleMetaphone returns the primary and alternate Double Metaphone codes
+// of a word. It follows the original algorithm of Lawrence Philips.
+func doubleMetaphone(word string) (string, string) {
+	dm := &metaphone{value: []rune(strings.ToUpper(strings.TrimSpace(word)))}
+	if len(dm.value) == 0 {
+		return "", ""
+	}
+	dm.slavoGermanic = dm.isSlavoGermanic()
+
+	index := 0
+	if dm.contains(0, 2, "GN", "KN", "PN", "WR", "PS") {
+		index = 1
+	}
+
+	for !dm.complete() && index < len(dm.value) {
+		switch dm.at(index) {
+		case 'A', 'E', 'I', 'O', 'U', 'Y':
+			if index == 0 {
+				dm.add("A")
+			}
+			index++
+		case 'B':
+			dm.add("P")
+			index = dm.skip(index, 'B')
+		case 'Ç':
+			dm.add("S")
+			index++
+		case 'C':
+			index = dm.handleC(index)
+		case 'D':
+			index = dm.handleD(index)
+		case 'F':
+			dm.add("F")
+			index = dm.skip(index, 'F')
+		case 'G':
+			index = dm.handleG(index)
+		case 'H':
+			index = dm.handleH(index)
+		case 'J':
+			index = dm.handleJ(index)
+		case 'K':
+			dm.add("K")
+			index = dm.skip(index, 'K')
+		case 'L':
+			index = dm.handleL(index)
+		case 'M':
+			dm.add("M")
+			if dm.conditionM0(index) {
+				index += 2
+			} else {
+				index++
+			}
+		case 'N':
+			dm.add("N")
+			index = dm.skip(index, 'N')
+		case 'Ñ':
+			dm.add("N")
+			index++
+		case 'P':
+			index = dm.handleP(index)
+		case 'Q':
+			dm.add("K")
+			index = dm.skip(index, 'Q')
+		case 'R':
+			index = dm.handleR(index)
+		case 'S':
+			index = dm.handleS(index)
+		case 'T':
+			index = dm.handleT(index)
+		case 'V':
+			dm.add("F")
+			index = dm.skip(index, 'V')
+		case 'W':
+			index = dm.handleW(index)
+		case 'X':
+			index = dm.handleX(index)
+		case 'Z':
+			index = dm.handleZ(index)
+		default:
+			index++
+		}
+	}
+
+	return dm.primary.String(), dm.alternate.String()
+}
+
+// metaphone holds the state while encoding a word.
+type metaphone struct {
+	value         []rune
+	slavoGermanic bool
+	primary       strings.Builder
+	alternate     strings.Builder
+}
+
+func (dm *metaphone) complete() bool {
+	return dm.primary.Len() >= doubleMetaphoneLength && dm.alternate.Len() >= doubleMetaphoneLength
+}
+
+// add appends to both codes.
+func (dm *metaphone) add(code string) {
+	dm.addBoth(code, code)
+}
+
+// addBoth appends to the primary and the alternate code.
+func (dm *metaphone) addBoth(primary, alternate string) {
+	dm.addPrimary(primary)
+	dm.addAlternate(alternate)
+}
+
+func (dm *metaphone) addPrimary(code string) {
+	appendLimited(&dm.primary, code)
+}
+
+func (dm *metaphone) addAlternate(code string) {
+	appendLimited(&dm.alternate, code)
+}
+
+func appendLimited(b *strings.Builder, code string) {
+	if rest := doubleMetaphoneLength - b.Len(); rest < len(code) {
+		code = code[:max(rest, 0)]
+	}
+	b.WriteString(code)
+}
+
+// at returns the character at the index or 0 if it is out of range.
+func (dm *metaphone) at(index int) rune {
+	if index < 0 || index >= len(dm.value) {
+		return 0
+	}
+	return dm.value[index]
+}
+
+// skip returns the index after the character and a following equal one.
+func (dm *metaphone) skip(index int, c rune) int {
+	if dm.at(index+1) == c {
+		return index + 2
+	}
+	return index + 1
+}
+
+// contains tells if the text of the given length at start is one of the
+// criteria.
+func (dm *metaphone) contains(start, length int, criteria ...string) bool {
+	if start < 0 || start+length > len(dm.value) {
+		return false
+	}
+	target := string(dm.value[start : start+length])
+	for _, c := range criteria {
+		if target == c {
+			return true
+		}
+	}
+	return false
+}
+
+func (dm *metaphone) isVowel(index int) bool {
+	return strings.ContainsRune("AEIOUY", dm.at(index))
+}
+
+func (dm *metaphone) isSlavoGermanic() bool {
+	s := string(dm.value)
+	return strings.ContainsAny(s, "WK") || strings.Contains(s, "CZ") || strings.Contains(s, "WITZ")
+}
+
+func (dm *metaphone) last() int {
+	return len(dm.value) - 1
+}
+
+func (dm *metaphone) handleC(index int) int {
+	switch {
+	case dm.conditionC0(index):
+		dm.add("K")
+		return index + 2
+	case index == 0 && dm.contains(index, 6, "CAESAR"):
+		dm.add("S")
+		return index + 2
+	case dm.contains(index, 2, "CH"):
+		return dm.handleCH(index)
+	case dm.contains(index, 2, "CZ") && !dm.contains(index-2, 4, "WICZ"):
+		dm.addBoth("S", "X")
+		return index + 2
+	case dm.contains(index+1, 3, "CIA"):
+		dm.add("X")
+		return index + 3
+	case dm.contains(index, 2, "CC") && !(index == 1 && dm.at(0) == 'M'):
+		return dm.handleCC(index)
+	case dm.contains(index, 2, "CK", "CG", "CQ"):
+		dm.add("K")
+		return index + 2
+	case dm.contains(index, 2, "CI", "CE", "CY"):
+		if dm.contains(index, 3, "CIO", "CIE", "CIA") {
+			dm.addBoth("S", "X")
+		} else {
+			dm.add("S")
+		}
+		return index + 2
+	}
+
+	dm.add("K")
+	switch {
+	case dm.contains(index+1, 2, " C", " Q", " G"):
+		return index + 3
+	case dm.contains(index+1, 1, "C", "K", "Q") && !dm.contains(index+1, 2, "CE", "CI"):
+		return index + 2
+	}
+	return index + 1
+}
+
+func (dm *metaphone) conditionC0(index int) bool {
+	switch {
+	case dm.contains(index, 4, "CHIA"):
+		return true
+	case index <= 1:
+		return false
+	case dm.isVowel(index - 2):
+		return false
+	case !dm.contains(index-1, 3, "ACH"):
+		return false
+	}
+	c := dm.at(index + 2)
+	return (c != 'I' && c != 'E') || dm.contains(index-2, 6, "BACHER", "MACHER")
+}
+
+func (dm *metaphone) handleCC(index int) int {
+	if dm.contains(index+2, 1, "I", "E", "H") && !dm.contains(index+2, 2, "HU") {
+		if (index == 1 && dm.at(index-1) == 'A') || dm.contains(index-1, 5, "UCCEE", "UCCES") {
+			dm.add("KS")
+		} else {
+			dm.add("X")
+		}
+		return index + 3
+	}
+	dm.add("K")
+	return index + 2
+}
+
+func (dm *metaphone) handleCH(index int) int {
+	switch {
+	case index > 0 && dm.contains(index, 4, "CHAE"):
+		dm.addBoth("K", "X")
+	case dm.conditionCH0(index), dm.conditionCH1(index):
+		dm.add("K")
+	case index > 0:
+		if dm.contains(0, 2, "MC") {
+			dm.add("K")
+		} else {
+			dm.addBoth("X", "K")
+		}
+	default:
+		dm.add("X")
+	}
+	return index + 2
+}
+
+func (dm *metaphone) conditionCH0(index int) bool {
+	if index != 0 {
+		return false
+	}
+	if !dm.contains(index+1, 5, "HARAC", "HARIS") && !dm.contains(index+1, 3, "HOR", "HYM", "HIA", "HEM") {
+		return false
+	}
+	return !dm.contains(0, 5, "CHORE")
+}
+
+func (dm *metaphone) conditionCH1(index int) bool {
+	return dm.contains(0, 4, "VAN ", "VON ") || dm.contains(0, 3, "SCH") ||
+		dm.contains(index-2, 6, "ORCHES", "ARCHIT", "ORCHID") ||
+		dm.contains(index+2, 1, "T", "S") ||
+		((dm.contains(index-1, 1, "A", "O", "U", "E") || index == 0) &&
+			(dm.contains(index+2, 1, "L", "R", "N", "M", "B", "H", "F", "V", "W", " ") || index+1 == dm.last()))
+}
+
+func (dm *metaphone) handleD(index int) int {
+	switch {
+	case dm.contains(index, 2, "DG"):
+		if dm.contains(index+2, 1, "I", "E", "Y") {
+			dm.add("J")
+			return index + 3
+		}
+		dm.add("TK")
+		return index + 2
+	case dm.contains(index, 2, "DT", "DD"):
+		dm.add("T")
+		return index + 2
+	}
+	dm.add("T")
+	return index + 1
+}
+
+func (dm *metaphone) handleG(index int) int {
+	switch {
+	case dm.at(index+1) == 'H':
+		return dm.handleGH(index)
+
+	case dm.at(index+1) == 'N':
+		switch {
+		case index == 1 && dm.isVowel(0) && !dm.slavoGermanic:
+			dm.addBoth("KN", "N")
+		case !dm.contains(index+2, 2, "EY") && dm.at(index+1) != 'Y' && !dm.slavoGermanic:
+			dm.addBoth("N", "KN")
+		default:
+			dm.add("KN")
+		}
+		return index + 2
+
+	case dm.contains(index+1, 2, "LI") && !dm.slavoGermanic:
+		dm.addBoth("KL", "L")
+		return index + 2
+
+	case index == 0 && (dm.at(index+1) == 'Y' ||
+		dm.contains(index+1, 2, "ES", "EP", "EB", "EL", "EY", "IB", "IL", "IN", "IE", "EI", "ER")):
+		dm.addBoth("K", "J")
+		return index + 2
+
+	case (dm.contains(index+1, 2, "ER") || dm.at(index+1) == 'Y') &&
+		!dm.contains(0, 6, "DANGER", "RANGER", "MANGER") &&
+		!dm.contains(index-1, 1, "E", "I") &&
+		!dm.contains(index-1, 3, "RGY", "OGY"):
+		dm.addBoth("K", "J")
+		return index + 2
+
+	case dm.contains(index+1, 1, "E", "I", "Y") || dm.contains(index-1, 4, "AGGI", "OGGI"):
+		switch {
+		case dm.contains(0, 4, "VAN ", "VON ") || dm.contains(0, 3, "SCH") || dm.contains(index+1, 2, "ET"):
+			dm.add("K")
+		case dm.contains(index+1, 3, "IER"):
+			dm.add("J")
+		default:
+			dm.addBoth("J", "K")
+		}
+		return index + 2
+	}
+
+	dm.add("K")
+	return dm.skip(index, 'G')
+}
+
+func (dm *metaphone) handleGH(index int) int {
+	switch {
+	case index > 0 && !dm.isVowel(index-1):
+		dm.add("K")
+	case index == 0:
+		if dm.at(index+2) == 'I' {
+			dm.add("J")
+		} else {
+			dm.add("K")
+		}
+	case (index > 1 && dm.contains(index-2, 1, "B", "H", "D")) ||
+		(index > 2 && dm.contains(index-3, 1, "B", "H", "D")) ||
+		(index > 3 && dm.contains(index-4, 1, "B", "H")):
+		// Silent as in "night".
+	case index > 2 && dm.at(index-1) == 'U' && dm.contains(index-3, 1, "C", "G", "L", "R", "T"):
+		dm.add("F")
+	case dm.at(index-1) != 'I':
+		dm.add("K")
+	}
+	return index + 2
+}
+
+func (dm *metaphone) handleH(index int) int {
+	if (index == 0 || dm.isVowel(index-1)) && dm.isVowel(index+1) {
+		dm.add("H")
+		return index + 2
+	}
+	return index + 1
+}
+
+func (dm *metaphone) handleJ(index int) int {
+	if dm.contains(index, 4, "JOSE") || dm.contains(0, 4, "SAN ") {
+		if (index == 0 && dm.at(index+4) == ' ') || len(dm.value) == 4 || dm.contains(0, 4, "SAN ") {
+			dm.add("H")
+		} else {
+			dm.addBoth("J", "H")
+		}
+		return index + 1
+	}
+
+	switch {
+	case index == 0:
+		dm.addBoth("J", "A")
+	case dm.isVowel(index-1) && !dm.slavoGermanic && (dm.at(index+1) == 'A' || dm.at(index+1) == 'O'):
+		dm.addBoth("J", "H")
+	case index == dm.last():
+		dm.addPrimary("J")
+	case !dm.contains(index+1, 1, "L", "T", "K", "S", "N", "M", "B", "Z") && !dm.contains(index-1, 1, "S", "K", "L"):
+		dm.add("J")
+	}
+	return dm.skip(index, 'J')
+}
+
+func (dm *metaphone) handleL(index int) int {
+	if dm.at(index+1) != 'L' {
+		dm.add("L")
+		return index + 1
+	}
+	if dm.conditionL0(index) {
+		dm.addPrimary("L")
+	} else {
+		dm.add("L")
+	}
+	return index + 2
+}
+
+func (dm *metaphone) conditionL0(index int) bool {
+	if index == len(dm.value)-3 && dm.contains(index-1, 4, "ILLO", "ILLA", "ALLE") {
+		return true
+	}
+	return (dm.contains(len(dm.value)-2, 2, "AS", "OS") || dm.contains(dm.last(), 1, "A", "O")) &&
+		dm.contains(index-1, 4, "ALLE")
+}
+
+func (dm *metaphone) conditionM0(index int) bool {
+	if dm.at(index+1) == 'M' {
+		return true
+	}
+	return dm.contains(index-1, 3, "UMB") && (index+1 == dm.last() || dm.contains(index+2, 2, "ER"))
+}
+
+func (dm *metaphone) handleP(index int) int {
+	if dm.at(index+1) == 'H' {
+		dm.add("F")
+		return index + 2
+	}
+	dm.add("P")
+	if dm.contains(index+1, 1, "P", "B") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (dm *metaphone) handleR(index int) int {
+	if index == dm.last() && !dm.slavoGermanic &&
+		dm.contains(index-2, 2, "IE") && !dm.contains(index-4, 2, "ME", "MA") {
+		dm.addAlternate("R")
+	} else {
+		dm.add("R")
+	}
+	return dm.skip(index, 'R')
+}
+
+func (dm *metaphone) handleS(index int) int {
+	switch {
+	case dm.contains(index-1, 3, "ISL", "YSL"):
+		// Silent as in "island".
+		return index + 1
+
+	case index == 0 && dm.contains(index, 5, "SUGAR"):
+		dm.addBoth("X", "S")
+		return index + 1
+
+	case dm.contains(index, 2, "SH"):
+		if dm.contains(index+1, 4, "HEIM", "HOEK", "HOLM", "HOLZ") {
+			dm.add("S")
+		} else {
+			dm.add("X")
+		}
+		return index + 2
+
+	case dm.contains(index, 3, "SIO", "SIA") || dm.contains(index, 4, "SIAN"):
+		if dm.slavoGermanic {
+			dm.add("S")
+		} else {
+			dm.addBoth("S", "X")
+		}
+		return index + 3
+
+	case (index == 0 && dm.contains(index+1, 1, "M", "N", "L", "W")) || dm.contains(index+1, 1, "Z"):
+		dm.addBoth("S", "X")
+		if dm.contains(index+1, 1, "Z") {
+			return index + 2
+		}
+		return index + 1
+
+	case dm.contains(index, 2, "SC"):
+		return dm.handleSC(index)
+	}
+
+	if index == dm.last() && dm.contains(index-2, 2, "AI", "OI") {
+		// Silent as in French "bois".
+		dm.addAlternate("S")
+	} else {
+		dm.add("S")
+	}
+	if dm.contains(index+1, 1, "S", "Z") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (dm *metaphone) handleSC(index int) int {
+	switch {
+	case dm.at(index+2) == 'H':
+		switch {
+		case dm.contains(index+3, 2, "ER", "EN"):
+			dm.addBoth("X", "SK")
+		case dm.contains(index+3, 2, "OO", "UY", "ED", "EM"):
+			dm.add("SK")
+		case index == 0 && !dm.isVowel(3) && dm.at(3) != 'W':
+			dm.addBoth("X", "S")
+		default:
+			dm.add("X")
+		}
+	case dm.contains(index+2, 1, "I", "E", "Y"):
+		dm.add("S")
+	default:
+		dm.add("SK")
+	}
+	return index + 3
+}
+
+func (dm *metaphone) handleT(index int) int {
+	switch {
+	case dm.contains(index, 4, "TION"), dm.contains(index, 3, "TIA", "TCH"):
+		dm.add("X")
+		return index + 3
+
+	case dm.contains(index, 2, "TH") || dm.contains(index, 3, "TTH"):
+		if dm.contains(index+2, 2, "OM", "AM") || dm.contains(0, 4, "VAN ", "VON ") || dm.contains(0, 3, "SCH") {
+			dm.add("T")
+		} else {
+			dm.addBoth("0", "T")
+		}
+		return index + 2
+	}
+
+	dm.add("T")
+	if dm.contains(index+1, 1, "T", "D") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (dm *metaphone) handleW(index int) int {
+	switch {
+	case dm.contains(index, 2, "WR"):
+		dm.add("R")
+		return index + 2
+
+	case index == 0 && (dm.isVowel(index+1) || dm.contains(index, 2, "WH")):
+		if dm.isVowel(index + 1) {
+			dm.addBoth("A", "F")
+		} else {
+			dm.add("A")
+		}
+
+	case (index == dm.last() && dm.isVowel(index-1)) ||
+		dm.contains(index-1, 5, "EWSKI", "EWSKY", "OWSKI", "OWSKY") ||
+		dm.contains(0, 3, "SCH"):
+		dm.addAlternate("F")
+
+	case dm.contains(index, 4, "WICZ", "WITZ"):
+		dm.addBoth("TS", "FX")
+		return index + 4
+	}
+	return index + 1
+}
+
+func (dm *metaphone) handleX(index int) int {
+	if index == 0 {
+		dm.add("S")
+		return index + 1
+	}
+
+	// Silent as in French "breaux".
+	if !(index == dm.last() && (dm.contains(index-3, 3, "IAU", "EAU") || dm.contains(index-2, 2, "AU", "OU"))) {
+		dm.add("KS")
+	}
+	if dm.contains(index+1, 1, "C", "X") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (dm *metaphone) handleZ(index int) int {
+	if dm.at(index+1) == 'H' {
+		dm.add("J")
+		return index + 2
+	}
+
+	if dm.contains(index+1, 2, "ZO", "ZI", "ZA") || (dm.slavoGermanic && index > 0 && dm.at(index-1) != 'T') {
+		dm.addBoth("S", "TS")
+	} else {
+		dm.add("S")
+	}
+	return dm.skip(index, 'Z')
+}
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"context"
+
+	"github.com/blevesearch/bleve/v2/mapping"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// Names of the sub-queries of a search shown in explanations.
+const (
+	subQueryOriginal    = "original"
+	subQuerySubstring   = "substring"
+	subQueryFuzzy       = "fuzzy"
+	subQueryScope       = "scope_filter"
+	subQueryCollections = "collection_filter"
+	subQueryDates       = "date_filter"
+	subQueryFieldBoost  = "field_boost"
+	subQueryPhonetic    = "phonetic"
+	subQueryIdentifier  = "identifier"
+	subQuerySynonyms    = "synonyms"
+)
+
+// Explanation is the score explanation of a hit.
+type Explanation = bsearch.Explanation
+
+// labeled marks the explanations of q with the name of the sub-query when
+// explanations are requested.
+func labeled(name string, q query.Query, opts Options) query.Query {
+	if !opts.Explain {
+		return q
+	}
+	return &labeledQuery{name: name, query: q}
+}
+
+// labeledQuery wraps the explanations of a query into a node naming the
+// sub-query.
+type labeledQuery struct {
+	name  string
+	query query.Query
+}
+
+func (q *labeledQuery) Searcher(
+	ctx context.Context,
+	i index.IndexReader,
+	m mapping.IndexMapping,
+	options bsearch.SearcherOptions,
+) (bsearch.Searcher, error) {
+	s, err := q.query.Searcher(ctx, i, m, options)
+	if err != nil || !options.Explain {
+		return s, err
+	}
+	return &labeledSearcher{Searcher: s, name: q.name}, nil
+}
+
+type labeledSearcher struct {
+	bsearch.Searcher
+	name string
+}
+
+func (s *labeledSearcher) label(dm *bsearch.DocumentMatch, err error) (*bsearch.DocumentMatch, error) {
+	if dm != nil && dm.Expl != nil {
+		dm.Expl = &bsearch.Explanation{
+			Value:    dm.Expl.Value,
+			Message:  "query " + s.name + ":",
+			Children: []*bsearch.Explanation{dm.Expl},
+		}
+	}
+	return dm, err
+}
+
+func (s *labeledSearcher) Next(ctx *bsearch.SearchContext) (*bsearch.DocumentMatch, error) {
+	return s.label(s.Searcher.Next(ctx))
+}
+
+func (s *labeledSearcher) Advance(ctx *bsearch.SearchContext, id index.IndexInternalID) (*bsearch.DocumentMatch, error) {
+	return s.label(s.Searcher.Advance(ctx, id))
+}
+
+// contributions sums up the scores of the labeled sub-queries in an
+// explanation.
+func contributions(expl *bsearch.Explanation) map[string]float64 {
+	result := map[string]float64{}
+	var walk func(expl *bsearch.Explanation, factor float64)
+	walk = func(expl *bsearch.Explanation, factor float64) {
+		if expl == nil {
+			return
+		}
+		for _, name := range []string{subQueryOriginal, subQuerySubstring, subQueryFuzzy, subQueryScope, subQueryCollections, subQueryDates, subQueryFieldBoost, subQuerySynonyms, subQueryPhonetic, subQueryIdentifier} {
+			if expl.Message == "query "+name+":" {
+				result[name] += expl.Value * factor
+				return
+			}
+		}
+		// Disjunctions scale the sum of their children with a coord factor.
+		if expl.Message == "product of:" && len(expl.Children) == 2 && expl.Children[0].Value != 0 {
+			walk(expl.Children[0], factor*expl.Value/expl.Children[0].Value)
+			return
+		}
+		for _, child := range expl.Children {
+			walk(child, factor)
+		}
+	}
+	walk(expl, 1)
+	return result
+}
@@ -0,0 +1,257 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"encoding/json"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+// baseType returns the type of a meta model field without its
+// parameters, e.g. "decimal" for "decimal(6)".
+func baseType(fieldType string) string {
+	name, _, _ := strings.Cut(fieldType, "(")
+	return name
+}
+
+// fieldValue converts a value read from the database into the value of
+// the field in the index document. Returns false if the value is missing
+// or does not fit the field type.
+func fieldValue(fieldType string, v any) (any, bool) {
+	if v == nil {
+		return nil, false
+	}
+
+	switch baseType(fieldType) {
+	case "string", "text", "HTMLStrict", "HTMLPermissive", "generic-relation", "color":
+		s, ok := v.(string)
+		return s, ok
+	case "boolean":
+		b, ok := v.(bool)
+		return b, ok
+	case "number", "relation":
+		return toInt(v)
+	case "float", "decimal":
+		return toFloat(v)
+	case "timestamp", "date":
+		return toTime(v)
+	case "number[]", "relation-list":
+		values, ok := toSlice(v)
+		if !ok {
+			return nil, false
+		}
+		numbers := make([]int64, 0, len(values))
+		for _, value := range values {
+			if n, ok := toInt(value); ok {
+				numbers = append(numbers, n)
+			}
+		}
+		return numbers, true
+	case "string[]", "generic-relation-list":
+		values, ok := toSlice(v)
+		if !ok {
+			return nil, false
+		}
+		texts := make([]string, 0, len(values))
+		for _, value := range values {
+			if s, ok := value.(string); ok {
+				texts = append(texts, s)
+			}
+		}
+		return texts, true
+	case "JSON", "json-int-string-map":
+		return jsonText(v), true
+	}
+	return nil, false
+}
+
+func toInt(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		if n == math.Trunc(n) {
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case pgtype.Numeric:
+		f, err := n.Float64Value()
+		return f.Float64, err == nil && f.Valid
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	if i, ok := toInt(v); ok {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+// toTime converts timestamps. Numbers are seconds since the epoch like
+// they were stored by the datastore.
+func toTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		parsed, err := time.Parse(time.RFC3339, t)
+		return parsed, err == nil
+	}
+	if i, ok := toInt(v); ok {
+		return time.Unix(i, 0), true
+	}
+	return time.Time{}, false
+}
+
+// toSlice converts the arrays returned by pgx.
+func toSlice(v any) ([]any, bool) {
+	switch s := v.(type) {
+	case []any:
+		return s, true
+	case []string:
+		return convertSlice(s), true
+	case []int32:
+		return convertSlice(s), true
+	case []int64:
+		return convertSlice(s), true
+	case []int:
+		return convertSlice(s), true
+	}
+	return nil, false
+}
+
+func convertSlice[T any](s []T) []any {
+	rv := make([]any, len(s))
+	for i, v := range s {
+		rv[i] = v
+	}
+	return rv
+}
+
+// jsonText extracts the texts of a JSON value like the paragraphs of an
+// amendment. They are joined by new lines in the order of their keys.
+func jsonText(v any) string {
+	switch raw := v.(type) {
+	case []byte:
+		v = nil
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return string(raw)
+		}
+	case string:
+		// Only objects and arrays are decoded. Strings holding other JSON
+		// values like "123" or "true" are text themselves.
+		var decoded any
+		if err := json.Unmarshal([]byte(raw), &decoded); err == nil {
+			switch decoded.(type) {
+			case map[string]any, []any:
+				v = decoded
+			}
+		}
+	}
+
+	var texts []string
+	var collect func(any)
+	collect = func(v any) {
+		switch value := v.(type) {
+		case string:
+			if value != "" {
+				texts = append(texts, value)
+			}
+		case []any:
+			for _, item := range value {
+				collect(item)
+			}
+		case map[string]any:
+			for _, key := range slices.SortedFunc(maps.Keys(value), compareKeys) {
+				collect(value[key])
+			}
+		}
+	}
+	collect(v)
+	return strings.Join(texts, "\n")
+}
+
+// compareKeys orders numeric keys like the ones of json-int-string-map by
+// their value and all others alphabetically.
+func compareKeys(a, b string) int {
+	ia, errA := strconv.Atoi(a)
+	ib, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return ia - ib
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// computeValue fills the template of a computed field with the values of
+// the other fields. Returns nil if all of them are empty.
+func computeValue(template *meta.Template, data map[string]any) any {
+	text := template.Execute(func(field string) string {
+		return templateValue(data[field])
+	})
+	if text == "" {
+		return nil
+	}
+	return text
+}
+
+// templateValue converts a field value into text. Lists like the ones of
+// related fields are separated by spaces.
+func templateValue(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(value)
+	case []string:
+		return strings.Join(value, " ")
+	case time.Time:
+		return value.Format(time.DateOnly)
+	}
+	if values, ok := toSlice(v); ok {
+		texts := make([]string, 0, len(values))
+		for _, value := range values {
+			if text := templateValue(value); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		return strings.Join(texts, " ")
+	}
+	if i, ok := toInt(v); ok {
+		return strconv.FormatInt(i, 10)
+	}
+	if f, ok := toFloat(v); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestJSONText(t *testing.T) {
+	for _, tt := range []struct {
+		value  any
+		expect string
+	}{
+		{map[string]any{"10": "<p>c</p>", "2": "<p>b</p>", "1": "<p>a</p>"}, "<p>a</p>\n<p>b</p>\n<p>c</p>"},
+		{[]byte(`{"title": "x", "items": ["y", {"z": "z"}], "count": 3}`), "y\nz\nx"},
+		{`["a", "b"]`, "a\nb"},
+		{"plain", "plain"},
+		{"123", "123"},
+		{"true", "true"},
+		{`{"1": "a"}`, "a"},
+	} {
+		if got := jsonText(tt.value); got != tt.expect {
+			t.Errorf("jsonText(%v) = %q, expected %q", tt.value, got, tt.expect)
+		}
+	}
+}
+
+func TestFieldTypes(t *testing.T) {
+	collections := meta.Collections{
+		"motion": {Fields: map[string]*meta.Member{
+			"title":                {Type: "string", Searchable: true},
+			"text":                 {Type: "HTMLStrict", Searchable: true},
+			"amendment_paragraphs": {Type: "JSON", Searchable: true},
+			"paragraphs":           {Type: "json-int-string-map", Searchable: true},
+			"keywords":             {Type: "string[]", Searchable: true},
+			"is_active":            {Type: "boolean", Searchable: true},
+			"created":              {Type: "timestamp", Searchable: true},
+			"weight":               {Type: "decimal(6)", Searchable: true},
+			"ratio":                {Type: "float", Searchable: true},
+			"sequential_number":    {Type: "number", Searchable: true},
+			"tag_ids":              {Type: "relation-list", Searchable: true},
+			"option_ids":           {Type: "number[]", Searchable: true},
+			"content_object_ids":   {Type: "generic-relation-list", Searchable: true},
+			"color":                {Type: "color", Searchable: true},
+		}},
+	}
+
+	created := time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+
+	// Values like they are returned by pgx.
+	ti := newTestIndex(t, collections, map[string]map[string]any{
+		"motion/1": {
+			"title":                "Haushalt",
+			"text":                 "<p>Der Haushalt wird beschlossen.</p>",
+			"amendment_paragraphs": map[string]any{"3": "<p>Die Schulen werden saniert.</p>"},
+			"paragraphs":           map[string]any{"1": "<p>Radwege</p>"},
+			"keywords":             []any{"Finanzen", "Bildung"},
+			"is_active":            true,
+			"created":              created,
+			"weight":               pgtype.Numeric{Int: big.NewInt(15), Exp: -1, Valid: true},
+			"ratio":                float64(0.25),
+			"sequential_number":    int32(7),
+			"tag_ids":              []any{int32(4), int32(5)},
+			"option_ids":           []any{int32(9)},
+			"content_object_ids":   []any{"topic/1"},
+			"color":                "#ff0000",
+		},
+		"motion/2": {
+			"title":     "Satzung",
+			"is_active": false,
+			"created":   created.AddDate(0, -1, 0),
+		},
+	})
+
+	match := func(field, text string) query.Query {
+		q := bleve.NewMatchQuery(text)
+		q.SetField(field)
+		q.Analyzer = textAnalyzer(defaultLanguage)
+		return q
+	}
+	term := func(field, text string) query.Query {
+		q := bleve.NewTermQuery(text)
+		q.SetField(field)
+		return q
+	}
+	number := func(field string, n float64) query.Query {
+		inclusive := true
+		q := bleve.NewNumericRangeInclusiveQuery(&n, &n, &inclusive, &inclusive)
+		q.SetField(field)
+		return q
+	}
+	boolean := func(field string, b bool) query.Query {
+		q := bleve.NewBoolFieldQuery(b)
+		q.SetField(field)
+		return q
+	}
+	after := func(field string, start time.Time) query.Query {
+		q := bleve.NewDateRangeQuery(start, time.Time{})
+		q.SetField(field)
+		return q
+	}
+
+	for _, tt := range []struct {
+		name   string
+		query  query.Query
+		expect []string
+	}{
+		{"html", match("text", "beschlossen"), []string{"motion/1"}},
+		{"json", match("amendment_paragraphs", "Schulen"), []string{"motion/1"}},
+		{"json without markup", match("amendment_paragraphs", "p"), nil},
+		{"json-int-string-map", match("paragraphs", "Radwege"), []string{"motion/1"}},
+		{"string list", match("keywords", "Bildung"), []string{"motion/1"}},
+		{"boolean true", boolean("is_active", true), []string{"motion/1"}},
+		{"boolean false", boolean("is_active", false), []string{"motion/2"}},
+		{"timestamp", after("created", created.AddDate(0, 0, -1)), []string{"motion/1"}},
+		{"decimal", number("weight", 1.5), []string{"motion/1"}},
+		{"float", number("ratio", 0.25), []string{"motion/1"}},
+		{"number", number("sequential_number", 7), []string{"motion/1"}},
+		{"relation list", number("tag_ids", 5), []string{"motion/1"}},
+		{"number list", number("option_ids", 9), []string{"motion/1"}},
+		{"generic relation list", term("content_object_ids", "topic/1"), []string{"motion/1"}},
+		{"color", term("color", "#ff0000"), []string{"motion/1"}},
+		{"all", match("_all", "Finanzen"), []string{"motion/1"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchingDocs(t, ti, tt.query)
+			if !slices.Equal(got, tt.expect) {
+				t.Errorf("got %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/blevesearch/bleve/v2/search/query"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+// identifierAnalyzer indexes a field like a motion number as a single
+// normalized term.
+const identifierAnalyzer = "identifier"
+
+// identifierFilter is the token filter normalizing identifiers.
+const identifierFilter = "identifier_normalize"
+
+// identifierBoost weights exact identifier matches above full text ones.
+const identifierBoost = 10
+
+// identifierWords is the maximal number of words of the question joined
+// to one identifier, e.g. "A 12".
+const identifierWords = 3
+
+var identifierReplacer = strings.NewReplacer(
+	"ä", "a", "ö", "o", "ü", "u", "ß", "ss",
+)
+
+// normalizeIdentifier unifies the spelling of an identifier. It is lower
+// cased without umlauts, spaces and dashes, so "Ä1 - 003" becomes "a1003".
+func normalizeIdentifier(s string) string {
+	s = identifierReplacer.Replace(strings.ToLower(s))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.Is(unicode.Pd, r) || r == '_' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// isIdentifier tells if a field is analyzed as identifier.
+func isIdentifier(field *meta.Member) bool {
+	return field.Searchable && field.Analyzer != nil && *field.Analyzer == identifierAnalyzer
+}
+
+// buildIdentifierFields returns the names of all identifier fields.
+func buildIdentifierFields(collections meta.Collections) []string {
+	var fields []string
+	for _, col := range collections {
+		for fname, field := range col.Fields {
+			if isIdentifier(field) && !slices.Contains(fields, fname) {
+				fields = append(fields, fname)
+			}
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// identifierCandidates returns the normalized identifiers which may be
+// meant by the question. These are its words and the words joined with
+// their neighbors.
+func identifierCandidates(question string) []string {
+	words := strings.Fields(question)
+	for i, w := range words {
+		words[i] = strings.TrimLeft(strings.Trim(w, `"'`), "+")
+	}
+
+	var candidates []string
+	for i := range words {
+		for j := i + 1; j <= min(i+identifierWords, len(words)); j++ {
+			c := normalizeIdentifier(strings.Join(words[i:j], ""))
+			if c != "" && !slices.Contains(candidates, c) {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+	return candidates
+}
+
+// identifierQuery matches documents with an identifier field equal to
+// an identifier within the question. Returns nil if there are no
+// identifier fields.
+func (ti *TextIndex) identifierQuery(question string) query.Query {
+	candidates := identifierCandidates(question)
+	if len(ti.identifierFields) == 0 || len(candidates) == 0 {
+		return nil
+	}
+
+	var queries []query.Query
+	for _, field := range ti.identifierFields {
+		for _, c := range candidates {
+			tq := bleve.NewTermQuery(c)
+			tq.SetField(field)
+			tq.SetBoost(identifierBoost)
+			queries = append(queries, tq)
+		}
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+type identifierTokenFilter struct{}
+
+func (f *identifierTokenFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	rv := make(analysis.TokenStream, 0, len(input))
+	for _, token := range input {
+		if term := normalizeIdentifier(string(token.Term)); term != "" {
+			token.Term = []byte(term)
+			rv = append(rv, token)
+		}
+	}
+	return rv
+}
+
+func identifierAnalyzerConstructor(
+	config map[string]interface{},
+	cache *registry.Cache,
+) (analysis.Analyzer, error) {
+	singleTokenizer, err := cache.TokenizerNamed(single.Name)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := cache.TokenFilterNamed(identifierFilter)
+	if err != nil {
+		return nil, err
+	}
+	rv := analysis.DefaultAnalyzer{
+		Tokenizer:    singleTokenizer,
+		TokenFilters: []analysis.TokenFilter{filter},
+	}
+	return &rv, nil
+}
+
+func init() {
+	registry.RegisterTokenFilter(identifierFilter, func(map[string]interface{}, *registry.Cache) (analysis.TokenFilter, error) {
+		return &identifierTokenFilter{}, nil
+	})
+	registry.RegisterAnalyzer(identifierAnalyzer, identifierAnalyzerConstructor)
+	registry.RegisterAnalyzer(ngramAnalyzer(identifierAnalyzer), ngramAnalyzerConstructor(identifierAnalyzer))
+}
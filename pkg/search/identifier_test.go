@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestNormalizeIdentifier(t *testing.T) {
+	for _, tt := range []struct {
+		identifier string
+		expect     string
+	}{
+		{"Ä1-003", "a1003"},
+		{"ä1 – 003", "a1003"},
+		{"A 12", "a12"},
+		{"a12", "a12"},
+		{"S-4.2", "s4.2"},
+		{"TOP 3.1", "top3.1"},
+		{" - ", ""},
+	} {
+		if got := normalizeIdentifier(tt.identifier); got != tt.expect {
+			t.Errorf("normalizeIdentifier(%q) = %q, expected %q", tt.identifier, got, tt.expect)
+		}
+	}
+
+	got := identifierCandidates(`Antrag "A 12"`)
+	expect := []string{"antrag", "antraga", "antraga12", "a", "a12", "12"}
+	if !slices.Equal(got, expect) {
+		t.Errorf("got candidates %v, expected %v", got, expect)
+	}
+}
+
+func TestIdentifierSearch(t *testing.T) {
+	identifier := identifierAnalyzer
+	collections := meta.Collections{
+		"motion": {Fields: map[string]*meta.Member{
+			"number": {Type: "string", Searchable: true, Analyzer: &identifier},
+			"title":  {Type: "string", Searchable: true},
+		}},
+	}
+
+	ti := newTestIndex(t, collections, map[string]map[string]any{
+		"motion/1": {"number": "A 12", "title": "Haushalt"},
+		"motion/2": {"number": "A13", "title": "Änderung zu A12"},
+		"motion/3": {"number": "Ä1-003", "title": "Satzung"},
+	})
+
+	for _, tt := range []struct {
+		question string
+		expect   string
+	}{
+		{"A12", "motion/1"},
+		{"a 12", "motion/1"},
+		{"A-12", "motion/1"},
+		{"Antrag A1-003", "motion/3"},
+		{"ä1003", "motion/3"},
+	} {
+		t.Run(tt.question, func(t *testing.T) {
+			q := ti.buildQuery(tt.question, nil, nil, Options{IncludeArchived: true})
+			result, err := ti.index.Search(bleve.NewSearchRequest(q))
+			if err != nil {
+				t.Fatalf("searching failed: %v", err)
+			}
+			if len(result.Hits) == 0 || result.Hits[0].ID != tt.expect {
+				t.Errorf("got hits %v, expected %s first", result.Hits, tt.expect)
+			}
+		})
+	}
+}
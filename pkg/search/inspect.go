@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/numeric"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// IndexStats contains statistics about the text index.
+type IndexStats struct {
+	Total     uint64            `json:"total"`
+	Documents map[string]uint64 `json:"documents"`
+	DiskSize  int64             `json:"disk_size"`
+}
+
+// DocumentInfo describes a document as it is stored in the text index.
+type DocumentInfo struct {
+	FQID string `json:"fqid"`
+	// Fields are the stored fields. Fields with several values are
+	// returned as lists.
+	Fields map[string]any `json:"fields"`
+	// Terms are the terms each field was analyzed into.
+	Terms map[string][]string `json:"terms"`
+}
+
+// stats collects the document counts and the size of the text index.
+func (ti *TextIndex) stats(ctx context.Context) (*IndexStats, error) {
+	request := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), 0, 0, false)
+	request.AddFacet("collections", bleve.NewFacetRequest("_bleve_type", len(ti.collections)))
+
+	result, err := ti.index.SearchInContext(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("counting documents failed: %w", err)
+	}
+
+	stats := &IndexStats{
+		Total:     result.Total,
+		Documents: map[string]uint64{},
+	}
+	if facet, ok := result.Facets["collections"]; ok {
+		for _, term := range facet.Terms.Terms() {
+			stats.Documents[term.Term] = uint64(term.Count)
+		}
+	}
+
+	if err := filepath.WalkDir(ti.cfg.Index.File, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		stats.DiskSize += info.Size()
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("measuring index size failed: %w", err)
+	}
+
+	return stats, nil
+}
+
+// inspect returns the stored fields and terms of a document.
+// Returns nil if the document is not indexed.
+func (ti *TextIndex) inspect(fqid string) (*DocumentInfo, error) {
+	doc, err := ti.index.Document(fqid)
+	if err != nil {
+		return nil, fmt.Errorf("loading document %q failed: %w", fqid, err)
+	}
+	if doc == nil {
+		return nil, nil
+	}
+
+	info := &DocumentInfo{
+		FQID:   fqid,
+		Fields: map[string]any{},
+		Terms:  map[string][]string{},
+	}
+
+	var names []string
+	numericFields := map[string]bool{}
+	doc.VisitFields(func(field index.Field) {
+		value, isNumeric := storedValue(field)
+		name := field.Name()
+		numericFields[name] = isNumeric
+		switch existing := info.Fields[name].(type) {
+		case nil:
+			info.Fields[name] = value
+			names = append(names, name)
+		case []any:
+			info.Fields[name] = append(existing, value)
+		default:
+			info.Fields[name] = []any{existing, value}
+		}
+	})
+
+	advanced, err := ti.index.Advanced()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := advanced.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	internalID, err := reader.InternalID(fqid)
+	if err != nil {
+		return nil, fmt.Errorf("looking up document %q failed: %w", fqid, err)
+	}
+
+	dvReader, err := reader.DocValueReader(names)
+	if err != nil {
+		return nil, err
+	}
+	if err := dvReader.VisitDocValues(internalID, func(field string, term []byte) {
+		if numericFields[field] {
+			// Numbers are indexed with several precisions. Only the
+			// exact one is of interest.
+			coded := numeric.PrefixCoded(term)
+			if shift, err := coded.Shift(); err != nil || shift != 0 {
+				return
+			}
+			i64, err := coded.Int64()
+			if err != nil {
+				return
+			}
+			term = []byte(strconv.FormatFloat(numeric.Int64ToFloat64(i64), 'f', -1, 64))
+		}
+		info.Terms[field] = append(info.Terms[field], string(term))
+	}); err != nil {
+		return nil, fmt.Errorf("reading terms of %q failed: %w", fqid, err)
+	}
+
+	for field := range info.Terms {
+		slices.Sort(info.Terms[field])
+	}
+
+	return info, nil
+}
+
+// storedValue decodes the stored value of a field.
+func storedValue(field index.Field) (any, bool) {
+	switch f := field.(type) {
+	case index.NumericField:
+		if n, err := f.Number(); err == nil {
+			return n, true
+		}
+	case index.BooleanField:
+		if b, err := f.Boolean(); err == nil {
+			return b, false
+		}
+	case index.DateTimeField:
+		if t, _, err := f.DateTime(); err == nil {
+			return t, false
+		}
+	case index.TextField:
+		return f.Text(), false
+	}
+	return string(field.Value()), false
+}
@@ -0,0 +1,210 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
+	bleveHtml "github.com/blevesearch/bleve/v2/analysis/char/html"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/analysis/lang/es"
+	"github.com/blevesearch/bleve/v2/analysis/lang/fr"
+	"github.com/blevesearch/bleve/v2/analysis/lang/it"
+	"github.com/blevesearch/bleve/v2/analysis/lang/ru"
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/blevesearch/bleve/v2/search/query"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+// languageField holds the language a document is analyzed in.
+const languageField = "_language"
+
+// defaultLanguage is used for documents of unsupported languages. Its
+// documents are mapped by the plain collection name.
+const defaultLanguage = "de"
+
+// languageAnalyzers maps the supported languages to the bleve analyzers
+// their text analyzers are based on. German has its own analyzers.
+var languageAnalyzers = map[string]string{
+	"en": en.AnalyzerName,
+	"es": es.AnalyzerName,
+	"fr": fr.AnalyzerName,
+	"it": it.AnalyzerName,
+	"ru": ru.AnalyzerName,
+}
+
+// languages returns all supported languages, the default one first.
+func languages() []string {
+	return append([]string{defaultLanguage}, slices.Sorted(maps.Keys(languageAnalyzers))...)
+}
+
+// supportedLanguage returns the language if it is supported or fallback
+// otherwise.
+func supportedLanguage(language any, fallback string) string {
+	if l, ok := language.(string); ok {
+		if _, ok := languageAnalyzers[l]; ok || l == defaultLanguage {
+			return l
+		}
+	}
+	return fallback
+}
+
+// textAnalyzer returns the name of the analyzer of plain text in the
+// language.
+func textAnalyzer(language string) string {
+	return language + "_text"
+}
+
+// htmlAnalyzer returns the name of the analyzer of html in the language.
+func htmlAnalyzer(language string) string {
+	return language + "_html"
+}
+
+// typeName returns the name of the document mapping of a collection in
+// the language.
+func typeName(col string, language string) string {
+	if language == defaultLanguage || language == "" {
+		return col
+	}
+	return col + "." + language
+}
+
+// languageOf returns the language of a database row. It is the language
+// of its meeting or the default language of the organization.
+func (ti *TextIndex) languageOf(col string, id int, mcol *meta.Collection, data map[string]any) string {
+	return ti.meetingLanguage(meetingOf(col, id, mcol, data))
+}
+
+// meetingLanguage returns the language of a meeting. Meetings without a
+// supported language use the language of the organization.
+func (ti *TextIndex) meetingLanguage(id int) string {
+	if language := ti.meetingLanguages[id]; language != "" {
+		return language
+	}
+	return ti.organizationLanguage
+}
+
+// trackLanguage updates the known language of a meeting or the
+// organization. It returns true if the language has changed.
+func (ti *TextIndex) trackLanguage(evt updateEventType, col string, id int, data map[string]any) bool {
+	switch col {
+	case "meeting":
+		if evt == removeEvent {
+			delete(ti.meetingLanguages, id)
+			return false
+		}
+		old, ok := ti.meetingLanguages[id]
+		language := supportedLanguage(data["language"], "")
+		ti.meetingLanguages[id] = language
+		return ok && old != language
+
+	case "organization":
+		if evt == removeEvent {
+			return false
+		}
+		old := ti.organizationLanguage
+		ti.organizationLanguage = supportedLanguage(data["default_language"], defaultLanguage)
+		return old != ti.organizationLanguage
+	}
+	return false
+}
+
+// queryLanguages returns the languages a question is analyzed in. These
+// are the languages of the meetings in the scope or all languages in use.
+func (ti *TextIndex) queryLanguages(scope Scope) []string {
+	used := map[string]struct{}{}
+	if meetingIDs := scope[meta.ScopeMeeting]; len(meetingIDs) > 0 {
+		for _, id := range meetingIDs {
+			used[ti.meetingLanguage(id)] = struct{}{}
+		}
+	} else {
+		used[ti.organizationLanguage] = struct{}{}
+		for id := range ti.meetingLanguages {
+			used[ti.meetingLanguage(id)] = struct{}{}
+		}
+	}
+	return slices.Sorted(maps.Keys(used))
+}
+
+// perLanguage builds a query for each language. The queries are combined
+// by a disjunction. Returns nil if no query was built.
+func perLanguage(languages []string, build func(language string) query.Query) query.Query {
+	var queries []query.Query
+	for _, language := range languages {
+		if q := build(language); q != nil {
+			queries = append(queries, q)
+		}
+	}
+
+	switch len(queries) {
+	case 0:
+		return nil
+	case 1:
+		return queries[0]
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+// setAnalyzer sets the analyzer of all match queries within q.
+func setAnalyzer(q query.Query, analyzer string) {
+	switch q := q.(type) {
+	case *query.MatchQuery:
+		q.Analyzer = analyzer
+	case *query.MatchPhraseQuery:
+		q.Analyzer = analyzer
+	case *query.BooleanQuery:
+		for _, sub := range []query.Query{q.Must, q.Should, q.MustNot, q.Filter} {
+			if sub != nil {
+				setAnalyzer(sub, analyzer)
+			}
+		}
+	case *query.ConjunctionQuery:
+		for _, sub := range q.Conjuncts {
+			setAnalyzer(sub, analyzer)
+		}
+	case *query.DisjunctionQuery:
+		for _, sub := range q.Disjuncts {
+			setAnalyzer(sub, analyzer)
+		}
+	}
+}
+
+// languageAnalyzerConstructor returns a constructor of the text analyzer
+// of a language. It is the bleve analyzer of the language.
+func languageAnalyzerConstructor(base string) registry.AnalyzerConstructor {
+	return func(config map[string]interface{}, cache *registry.Cache) (analysis.Analyzer, error) {
+		return cache.AnalyzerNamed(base)
+	}
+}
+
+// languageHTMLAnalyzerConstructor returns a constructor of the html
+// analyzer of a language. It strips the tags before the text analyzer.
+func languageHTMLAnalyzerConstructor(language string) registry.AnalyzerConstructor {
+	return func(config map[string]interface{}, cache *registry.Cache) (analysis.Analyzer, error) {
+		analyzer, err := cache.AnalyzerNamed(textAnalyzer(language))
+		if err != nil {
+			return nil, err
+		}
+		htmlFilter, err := cache.CharFilterNamed(bleveHtml.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		rv := *analyzer.(*analysis.DefaultAnalyzer)
+		rv.CharFilters = []analysis.CharFilter{htmlFilter, &specialCharFilter{}}
+		return &rv, nil
+	}
+}
+
+func init() {
+	for language, base := range languageAnalyzers {
+		registry.RegisterAnalyzer(textAnalyzer(language), languageAnalyzerConstructor(base))
+		registry.RegisterAnalyzer(htmlAnalyzer(language), languageHTMLAnalyzerConstructor(language))
+	}
+}
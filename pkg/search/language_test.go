@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestLanguages(t *testing.T) {
+	collections := meta.Collections{
+		"motion": {Fields: map[string]*meta.Member{
+			"title":      {Type: "string", Searchable: true},
+			"meeting_id": {Type: "relation", Scopes: []string{meta.ScopeMeeting}},
+		}},
+	}
+
+	ti := newTestIndex(t, collections, nil)
+	ti.meetingLanguages = map[int]string{1: "en", 2: ""}
+	indexTestDocs(t, ti, map[string]map[string]any{
+		"motion/1": {"title": "Running meetings", "meeting_id": 1},
+		"motion/2": {"title": "Laufende Versammlungen", "meeting_id": 2},
+	})
+
+	for _, tt := range []struct {
+		question string
+		scope    Scope
+		expect   []string
+	}{
+		{"runs", Scope{meta.ScopeMeeting: {1}}, []string{"motion/1"}},
+		{"runs", Scope{meta.ScopeMeeting: {2}}, nil},
+		{"Versammlung", Scope{meta.ScopeMeeting: {2}}, []string{"motion/2"}},
+		{"meeting", nil, []string{"motion/1"}},
+		{"Versammlung", nil, []string{"motion/2"}},
+	} {
+		t.Run(tt.question, func(t *testing.T) {
+			q := ti.buildQuery(tt.question, nil, tt.scope, Options{IncludeArchived: true})
+			result, err := ti.index.Search(bleve.NewSearchRequest(q))
+			if err != nil {
+				t.Fatalf("searching failed: %v", err)
+			}
+
+			var got []string
+			for _, hit := range result.Hits {
+				got = append(got, hit.ID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.expect) {
+				t.Errorf("got %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		language any
+		expect   string
+	}{
+		{"en", "en"},
+		{"de", "de"},
+		{"cs", "de"},
+		{nil, "de"},
+	} {
+		if got := supportedLanguage(tt.language, defaultLanguage); got != tt.expect {
+			t.Errorf("supportedLanguage(%v) = %q, expected %q", tt.language, got, tt.expect)
+		}
+	}
+
+	for _, tt := range []struct {
+		language string
+		expect   string
+	}{
+		{"", textAnalyzer("de")},
+		{"de", textAnalyzer("de")},
+		{"en", textAnalyzer("en")},
+	} {
+		got, err := ti.FieldAnalyzer("motion/title", tt.language)
+		if err != nil {
+			t.Fatalf("FieldAnalyzer(%q) failed: %v", tt.language, err)
+		}
+		if got != tt.expect {
+			t.Errorf("FieldAnalyzer(%q) = %q, expected %q", tt.language, got, tt.expect)
+		}
+	}
+	if _, err := ti.FieldAnalyzer("motion/title", "cs"); err == nil {
+		t.Errorf("FieldAnalyzer of an unsupported language did not fail")
+	}
+
+	// Only the text fields differ between the languages.
+	types := ti.indexMapping.(*mapping.IndexMappingImpl).TypeMapping
+	de, en := types["motion"], types["motion.en"]
+	if de.Properties["meeting_id"] != en.Properties["meeting_id"] {
+		t.Errorf("scope field mapping is not shared between the languages")
+	}
+	if de.Properties["title"] == en.Properties["title"] {
+		t.Errorf("text field mapping is shared between the languages")
+	}
+}
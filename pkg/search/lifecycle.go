@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// meetingStateField holds the lifecycle state of the meeting a document
+// belongs to.
+const meetingStateField = "_meeting_state"
+
+// Lifecycle states of a meeting.
+const (
+	meetingActive   = "active"
+	meetingArchived = "archived"
+	meetingTemplate = "template"
+)
+
+// meetingState returns the lifecycle state of a meeting.
+func meetingState(active, template bool) string {
+	switch {
+	case template:
+		return meetingTemplate
+	case !active:
+		return meetingArchived
+	}
+	return meetingActive
+}
+
+// meetingStateFromData returns the lifecycle state of a meeting row.
+func meetingStateFromData(data map[string]any) string {
+	return meetingState(
+		data["is_active_in_organization_id"] != nil,
+		data["template_for_organization_id"] != nil)
+}
+
+// meetingOf returns the id of the meeting a database row belongs to.
+// It only looks at single valued meeting scope fields and returns 0
+// if none is found.
+func meetingOf(col string, id int, mcol *meta.Collection, data map[string]any) int {
+	if col == "meeting" {
+		return id
+	}
+	for fname, f := range mcol.Fields {
+		if !slices.Contains(f.Scopes, meta.ScopeMeeting) {
+			continue
+		}
+		switch v := data[fname].(type) {
+		case int32:
+			return int(v)
+		case int64:
+			return int(v)
+		case int:
+			return v
+		case string:
+			if c, mid, err := splitFqid(v); err == nil && c == "meeting" {
+				return mid
+			}
+		}
+	}
+	return 0
+}
+
+// trackMeeting updates the known lifecycle state of a meeting. It returns
+// true if the documents of the meeting have to be re-indexed. Unknown
+// meetings are considered active.
+func (ti *TextIndex) trackMeeting(evt updateEventType, id int, data map[string]any) bool {
+	if evt == removeEvent {
+		delete(ti.meetingStates, id)
+		return false
+	}
+
+	old, ok := ti.meetingStates[id]
+	if !ok {
+		old = meetingActive
+	}
+	state := meetingStateFromData(data)
+	ti.meetingStates[id] = state
+	return old != state
+}
+
+// reindexMeetings re-indexes all documents of the given meetings to store
+// their current lifecycle state and language.
+func (ti *TextIndex) reindexMeetings(ctx context.Context, meetingIDs []int) error {
+	fqids, err := ti.matchingIDs(ctx, ti.scopeQuery(Scope{meta.ScopeMeeting: meetingIDs}))
+	if err != nil {
+		return fmt.Errorf("searching documents of meetings failed: %w", err)
+	}
+	for _, id := range meetingIDs {
+		fqids = append(fqids, "meeting/"+strconv.Itoa(id))
+	}
+	return ti.reindex(ctx, fqids)
+}
+
+// reindexAll re-indexes all documents, e.g. after the language of the
+// organization has changed.
+func (ti *TextIndex) reindexAll(ctx context.Context) error {
+	fqids, err := ti.matchingIDs(ctx, bleve.NewMatchAllQuery())
+	if err != nil {
+		return fmt.Errorf("searching documents failed: %w", err)
+	}
+	return ti.reindex(ctx, fqids)
+}
+
+// reindex loads the given documents from the database and indexes them
+// again.
+func (ti *TextIndex) reindex(ctx context.Context, fqids []string) error {
+	batch, batchCount := ti.index.NewBatch(), 0
+	if err := ti.db.load(ctx, fqids, func(
+		_ updateEventType,
+		col string, id int, data map[string]any,
+	) error {
+		mcol := ti.collections[col]
+		if mcol == nil {
+			return nil
+		}
+		batch.Index(col+"/"+strconv.Itoa(id), ti.document(col, id, mcol, data))
+		if batchCount++; batchCount >= ti.cfg.Index.Batch {
+			if err := ti.index.Batch(batch); err != nil {
+				return err
+			}
+			batch, batchCount = ti.index.NewBatch(), 0
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if batchCount > 0 {
+		return ti.index.Batch(batch)
+	}
+	return nil
+}
+
+// excludeInactiveMeetings restricts q to documents which do not belong to
+// an archived or template meeting.
+func excludeInactiveMeetings(q query.Query) query.Query {
+	archivedQuery := bleve.NewTermQuery(meetingArchived)
+	archivedQuery.SetField(meetingStateField)
+
+	templateQuery := bleve.NewTermQuery(meetingTemplate)
+	templateQuery.SetField(meetingStateField)
+
+	bq := bleve.NewBooleanQuery()
+	bq.AddMust(q)
+	bq.AddMustNot(archivedQuery, templateQuery)
+	return bq
+}
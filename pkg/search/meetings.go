@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+	"github.com/OpenSlides/openslides-search-service/pkg/tracing"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// MeetingAnswers contains the hits of a search within one meeting.
+type MeetingAnswers struct {
+	Total   uint64
+	Answers map[string]Answer
+}
+
+// SearchMeetings searches each of the given meetings and the meetings of the
+// given committees separately. It returns up to size hits per meeting.
+// Meetings without hits are left out.
+func (ti *TextIndex) SearchMeetings(
+	ctx context.Context,
+	question string,
+	collections []string,
+	meetingIDs []int,
+	committeeIDs []int,
+	size int,
+	opts Options,
+) (map[int]MeetingAnswers, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "index.search_meetings")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		log.Debugf("searching meetings for %q took %v\n", question, time.Since(start))
+	}()
+
+	committeeMeetings, err := ti.committeeMeetings(ctx, committeeIDs, opts.IncludeArchived)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := ti.sortOrder(collections, opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	meetingIDs = slices.Concat(meetingIDs, committeeMeetings)
+	slices.Sort(meetingIDs)
+	meetingIDs = slices.Compact(meetingIDs)
+
+	results := map[int]MeetingAnswers{}
+	for _, meetingID := range meetingIDs {
+		if meetingID <= 0 {
+			continue
+		}
+
+		scope := Scope{meta.ScopeMeeting: {meetingID}}
+		request := bleve.NewSearchRequest(ti.buildQuery(question, collections, scope, opts))
+		request.IncludeLocations = true
+		request.Size = size
+		if order != nil {
+			request.SortByCustom(order)
+		}
+		if opts.Collapse {
+			request.Fields = []string{collapseField}
+		}
+
+		result, err := ti.index.SearchInContext(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Total == 0 {
+			continue
+		}
+
+		answers := answersFromResult(result)
+		if opts.Collapse {
+			answers = ti.collapse(answers, result, collections, order != nil)
+		}
+		results[meetingID] = MeetingAnswers{
+			Total:   result.Total,
+			Answers: answers,
+		}
+	}
+
+	return results, nil
+}
+
+// committeeMeetings returns the ids of the indexed meetings which belong to
+// one of the given committees. Archived and template meetings are left out
+// unless includeArchived is set.
+func (ti *TextIndex) committeeMeetings(ctx context.Context, committeeIDs []int, includeArchived bool) ([]int, error) {
+	scopeQuery := ti.scopeQuery(Scope{meta.ScopeCommittee: committeeIDs})
+	if scopeQuery == nil {
+		return nil, nil
+	}
+
+	fqids, err := ti.matchingIDs(ctx, bleve.NewConjunctionQuery(collectionsQuery([]string{"meeting"}), scopeQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	meetingIDs := make([]int, 0, len(fqids))
+	for _, fqid := range fqids {
+		_, id, err := splitFqid(fqid)
+		if err != nil {
+			continue
+		}
+		if !includeArchived && ti.meetingStates[id] != meetingActive {
+			continue
+		}
+		meetingIDs = append(meetingIDs, id)
+	}
+	return meetingIDs, nil
+}
@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/simple"
+	"github.com/blevesearch/bleve/v2/analysis/token/ngram"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/registry"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/blevesearch/bleve/v2/search/searcher"
+	index "github.com/blevesearch/bleve_index_api"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+// ngramPrefix prefixes the shadow fields holding the n-grams of the terms
+// of a searchable text field.
+const ngramPrefix = "_ngram_"
+
+// Lengths of the indexed n-grams. Words shorter than ngramMin are not
+// searched as substrings. Longer words than ngramMax are looked up by all
+// their n-grams of length ngramMax and checked against the terms of the
+// source field.
+const (
+	ngramMin = 3
+	ngramMax = 8
+)
+
+// ngramAnalyzer returns the name of the analyzer adding n-grams to the
+// terms of the given analyzer.
+func ngramAnalyzer(analyzer string) string {
+	return analyzer + "_ngram"
+}
+
+// ngramSources returns the fields of a searchable text field which get an
+// n-gram shadow field together with their analyzers in the language.
+func ngramSources(fname string, field *meta.Member, language string) map[string]string {
+	if !field.Searchable {
+		return nil
+	}
+
+	if field.Analyzer != nil {
+		switch *field.Analyzer {
+		case "html":
+			return map[string]string{fname: htmlAnalyzer(language)}
+		case "simple":
+			return map[string]string{fname: simple.Name}
+		}
+		// Custom analyzers have an n-gram variant in the index mapping.
+		return map[string]string{fname: *field.Analyzer}
+	}
+
+	switch field.Type {
+	case "HTMLStrict", "HTMLPermissive", "JSON", "json-int-string-map":
+		return map[string]string{fname: htmlAnalyzer(language)}
+	case "string[]":
+		return map[string]string{fname: textAnalyzer(language)}
+	case "string", "text":
+		return map[string]string{
+			fname:                     textAnalyzer(language),
+			"_" + fname + "_original": simple.Name,
+		}
+	}
+	return nil
+}
+
+// fillNgrams copies the value of a text field into its n-gram shadow fields.
+func (bt bleveType) fillNgrams(fname string, field *meta.Member, v any) {
+	for source := range ngramSources(fname, field, defaultLanguage) {
+		bt[ngramPrefix+source] = v
+	}
+}
+
+// addNgramFieldMappings adds the n-gram shadow fields of a field to the
+// document mapping of the language.
+func addNgramFieldMappings(docMapping *mapping.DocumentMapping, fname string, field *meta.Member, language string) {
+	for source, analyzer := range ngramSources(fname, field, language) {
+		fm := bleve.NewTextFieldMapping()
+		fm.Analyzer = ngramAnalyzer(analyzer)
+		fm.Store = false
+		fm.DocValues = false
+		fm.IncludeInAll = false
+		docMapping.AddFieldMappingsAt(ngramPrefix+source, fm)
+	}
+}
+
+// buildNgramFields returns the names of all n-gram shadow fields.
+func buildNgramFields(collections meta.Collections) []string {
+	var fields []string
+	for _, col := range collections {
+		for fname, field := range col.Fields {
+			for source := range ngramSources(fname, field, defaultLanguage) {
+				if !slices.Contains(fields, ngramPrefix+source) {
+					fields = append(fields, ngramPrefix+source)
+				}
+			}
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// ngramQuery matches documents containing the words as part of a term.
+// It replaces the expensive wildcard query *word*. The n-grams of long
+// words may come from different terms, so the candidates are checked
+// against the terms of the source field. Like the wildcard query, the
+// terms containing a word are reported as matched.
+func (ti *TextIndex) ngramQuery(words []string) query.Query {
+	var wordQueries []query.Query
+	for _, word := range words {
+		word = strings.ToLower(word)
+		length := utf8.RuneCountInString(word)
+		if length < ngramMin {
+			continue
+		}
+
+		grams := []string{word}
+		if length > ngramMax {
+			runes := []rune(word)
+			grams = grams[:0]
+			for i := 0; i+ngramMax <= len(runes); i++ {
+				grams = append(grams, string(runes[i:i+ngramMax]))
+			}
+		}
+
+		for _, field := range ti.ngramFields {
+			gramQueries := make([]query.Query, len(grams))
+			for i, gram := range grams {
+				tq := bleve.NewTermQuery(gram)
+				tq.SetField(field)
+				gramQueries[i] = tq
+			}
+
+			var q query.Query = gramQueries[0]
+			if len(gramQueries) > 1 {
+				q = bleve.NewConjunctionQuery(gramQueries...)
+			}
+			wordQueries = append(wordQueries, &substringFilterQuery{
+				field:      strings.TrimPrefix(field, ngramPrefix),
+				ngramField: field,
+				word:       word,
+				grams:      grams,
+				query:      q,
+			})
+		}
+	}
+
+	if len(wordQueries) == 0 {
+		return bleve.NewMatchNoneQuery()
+	}
+	return bleve.NewDisjunctionQuery(wordQueries...)
+}
+
+// substringFilterQuery keeps the documents matched by the n-grams of the
+// word which have a term containing the word in the field. The locations
+// of the n-grams are reported for these terms.
+type substringFilterQuery struct {
+	field      string
+	ngramField string
+	word       string
+	grams      []string
+	query      query.Query
+}
+
+func (q *substringFilterQuery) Searcher(
+	ctx context.Context,
+	i index.IndexReader,
+	m mapping.IndexMapping,
+	options bsearch.SearcherOptions,
+) (bsearch.Searcher, error) {
+	s, err := q.query.Searcher(ctx, i, m, options)
+	if err != nil {
+		return nil, err
+	}
+
+	dvReader, err := i.DocValueReader([]string{q.field})
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	word := []byte(q.word)
+	return searcher.NewFilteringSearcher(ctx, s, func(_ *bsearch.SearchContext, dm *bsearch.DocumentMatch) bool {
+		var terms []string
+		if err := dvReader.VisitDocValues(dm.IndexInternalID, func(_ string, term []byte) {
+			if bytes.Contains(term, word) {
+				terms = append(terms, string(term))
+			}
+		}); err != nil || len(terms) == 0 {
+			return false
+		}
+		q.reportTerms(dm, terms)
+		return true
+	}), nil
+}
+
+// reportTerms replaces the locations of the n-grams by locations of the
+// terms containing the word. The n-grams keep the position of their term,
+// so the first one is used.
+func (q *substringFilterQuery) reportTerms(dm *bsearch.DocumentMatch, terms []string) {
+	var location *bsearch.Location
+	kept := dm.FieldTermLocations[:0]
+	for _, ftl := range dm.FieldTermLocations {
+		if ftl.Field == q.ngramField && slices.Contains(q.grams, ftl.Term) {
+			if location == nil {
+				location = &bsearch.Location{
+					Pos:            ftl.Location.Pos,
+					Start:          ftl.Location.Start,
+					End:            ftl.Location.End,
+					ArrayPositions: slices.Clone(ftl.Location.ArrayPositions),
+				}
+			}
+			continue
+		}
+		kept = append(kept, ftl)
+	}
+	if location == nil {
+		// Locations are not requested.
+		return
+	}
+
+	for _, term := range terms {
+		kept = append(kept, bsearch.FieldTermLocation{Field: q.ngramField, Term: term, Location: *location})
+	}
+	dm.FieldTermLocations = kept
+}
+
+// ngramAnalyzerConstructor returns a constructor of an analyzer which adds
+// the n-grams of the terms of the given analyzer.
+func ngramAnalyzerConstructor(base string) registry.AnalyzerConstructor {
+	return func(config map[string]interface{}, cache *registry.Cache) (analysis.Analyzer, error) {
+		analyzer, err := cache.AnalyzerNamed(base)
+		if err != nil {
+			return nil, err
+		}
+
+		rv := *analyzer.(*analysis.DefaultAnalyzer)
+		rv.TokenFilters = append(slices.Clone(rv.TokenFilters), ngram.NewNgramFilter(ngramMin, ngramMax))
+		return &rv, nil
+	}
+}
+
+func init() {
+	registry.RegisterAnalyzer(ngramAnalyzer(simple.Name), ngramAnalyzerConstructor(simple.Name))
+	for _, language := range languages() {
+		for _, analyzer := range []string{textAnalyzer(language), htmlAnalyzer(language)} {
+			registry.RegisterAnalyzer(ngramAnalyzer(analyzer), ngramAnalyzerConstructor(analyzer))
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+var ngramTestCollections = meta.Collections{
+	"motion": {Fields: map[string]*meta.Member{
+		"title": {Type: "string", Searchable: true},
+		"text":  {Type: "HTMLStrict", Searchable: true},
+	}},
+}
+
+var ngramTestTitles = []string{
+	"Haushaltsantrag 2024",
+	"Änderung der Geschäftsordnung",
+	"Satzungsänderung zur Mitgliederversammlung",
+	"Antrag auf Vertagung",
+	"Verschiedenes",
+	// Has all 8-grams of "vertagung" but in different words.
+	"Vertagunx und Xertagung",
+}
+
+// ngramTestIndex creates an in memory index with n documents.
+func ngramTestIndex(tb testing.TB, n int) *TextIndex {
+	tb.Helper()
+
+	words, err := decompoundWords("")
+	if err != nil {
+		tb.Fatalf("loading words failed: %v", err)
+	}
+	im, err := buildIndexMapping(ngramTestCollections, words, nil)
+	if err != nil {
+		tb.Fatalf("building mapping failed: %v", err)
+	}
+
+	docs := make(map[string]map[string]any, n)
+	for i := range n {
+		title := ngramTestTitles[i%len(ngramTestTitles)]
+		docs[fmt.Sprintf("motion/%d", i)] = map[string]any{
+			"title": fmt.Sprintf("%s %d", title, i),
+			"text":  "<p>Der " + title + " wird beraten.</p>",
+		}
+	}
+	return newTestIndexWithMapping(tb, im, ngramTestCollections, docs)
+}
+
+// wildcardQuery is the former substring query.
+func wildcardQuery(words []string) query.Query {
+	var question strings.Builder
+	for _, w := range words {
+		question.WriteString("*" + strings.ToLower(w) + "* ")
+	}
+	return bleve.NewQueryStringQuery(question.String())
+}
+
+func TestNgramQuery(t *testing.T) {
+	ti := ngramTestIndex(t, 10)
+
+	for _, words := range [][]string{
+		{"ntra"},
+		{"Haushalt"},
+		{"ordnung"},
+		{"Geschäftsordnung"},
+		{"mitgliederversammlung"},
+		{"erat"},
+		{"schied", "tagung"},
+		{"vertagung"},
+		{"antragsteller"},
+		{"xyz"},
+	} {
+		t.Run(strings.Join(words, " "), func(t *testing.T) {
+			expect := matchingDocs(t, ti, wildcardQuery(words))
+			got := matchingDocs(t, ti, ti.ngramQuery(words))
+			if !slices.Equal(got, expect) {
+				t.Errorf("got %v, expected %v", got, expect)
+			}
+		})
+	}
+}
+
+// matchedWords returns the sorted matched words of all documents matching the
+// query.
+func matchedWords(tb testing.TB, ti *TextIndex, q query.Query) map[string]map[string][]string {
+	tb.Helper()
+
+	request := bleve.NewSearchRequestOptions(q, 1000, 0, false)
+	request.IncludeLocations = true
+	result, err := ti.index.Search(request)
+	if err != nil {
+		tb.Fatalf("searching failed: %v", err)
+	}
+
+	matched := map[string]map[string][]string{}
+	for fqid, answer := range answersFromResult(result) {
+		for _, words := range answer.MatchedWords {
+			slices.Sort(words)
+		}
+		matched[fqid] = answer.MatchedWords
+	}
+	return matched
+}
+
+func TestNgramMatchedWords(t *testing.T) {
+	ti := ngramTestIndex(t, 10)
+
+	for _, word := range []string{"ntra", "aushaltsantra", "geschäftsordnung", "vertagung"} {
+		t.Run(word, func(t *testing.T) {
+			expect := matchedWords(t, ti, wildcardQuery([]string{word}))
+			got := matchedWords(t, ti, ti.ngramQuery([]string{word}))
+			if !reflect.DeepEqual(got, expect) {
+				t.Errorf("got %v, expected %v", got, expect)
+			}
+		})
+	}
+
+	got := matchedWords(t, ti, ti.ngramQuery([]string{"aushaltsantra"}))
+	if words := got["motion/0"]["title"]; !slices.Equal(words, []string{"haushaltsantrag"}) {
+		t.Errorf("matched title words %v, expected [haushaltsantrag]", words)
+	}
+}
+
+func benchmarkSubstring(b *testing.B, q func(*TextIndex) query.Query) {
+	ti := ngramTestIndex(b, 5000)
+	request := bleve.NewSearchRequest(q(ti))
+
+	b.ResetTimer()
+	for b.Loop() {
+		if _, err := ti.index.Search(request); err != nil {
+			b.Fatalf("searching failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkSubstringWildcard(b *testing.B) {
+	benchmarkSubstring(b, func(*TextIndex) query.Query {
+		return wildcardQuery([]string{"ntra", "ordnung"})
+	})
+}
+
+func BenchmarkSubstringNgram(b *testing.B) {
+	benchmarkSubstring(b, func(ti *TextIndex) query.Query {
+		return ti.ngramQuery([]string{"ntra", "ordnung"})
+	})
+}
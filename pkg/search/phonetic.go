@@ -0,0 +1,272 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/blevesearch/bleve/v2/search/query"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+// phoneticPrefix prefixes the shadow fields holding the phonetic codes of
+// a text field.
+const phoneticPrefix = "_phonetic_"
+
+// phoneticBoost weights phonetic matches below exact ones.
+const phoneticBoost = 0.3
+
+// Names of the phonetic token filters.
+const (
+	koelnerPhoneticFilter = "phonetic_koelner"
+	doubleMetaphoneFilter = "phonetic_double_metaphone"
+)
+
+// phoneticAnalyzer returns the name of the analyzer creating the phonetic
+// codes of words in the language.
+func phoneticAnalyzer(language string) string {
+	return language + "_phonetic"
+}
+
+// isPhonetic tells if a field gets a phonetic shadow field.
+func isPhonetic(field *meta.Member) bool {
+	if !field.Searchable || !field.Phonetic {
+		return false
+	}
+	switch field.Type {
+	case "string", "text", "string[]", "HTMLStrict", "HTMLPermissive", "JSON", "json-int-string-map":
+		return true
+	}
+	return false
+}
+
+// fillPhonetic copies the value of a text field into its phonetic shadow
+// field.
+func (bt bleveType) fillPhonetic(fname string, field *meta.Member, v any) {
+	if isPhonetic(field) {
+		bt[phoneticPrefix+fname] = v
+	}
+}
+
+// addPhoneticFieldMapping adds the phonetic shadow field of a field to the
+// document mapping of the language. The codes have no locations, so they
+// are not reported as matched words.
+func addPhoneticFieldMapping(docMapping *mapping.DocumentMapping, fname string, field *meta.Member, language string) {
+	if !isPhonetic(field) {
+		return
+	}
+	fm := bleve.NewTextFieldMapping()
+	fm.Analyzer = phoneticAnalyzer(language)
+	fm.Store = false
+	fm.DocValues = false
+	fm.IncludeInAll = false
+	fm.IncludeTermVectors = false
+	docMapping.AddFieldMappingsAt(phoneticPrefix+fname, fm)
+}
+
+// buildPhoneticFields returns the names of all phonetic shadow fields.
+func buildPhoneticFields(collections meta.Collections) []string {
+	var fields []string
+	for _, col := range collections {
+		for fname, field := range col.Fields {
+			if isPhonetic(field) && !slices.Contains(fields, phoneticPrefix+fname) {
+				fields = append(fields, phoneticPrefix+fname)
+			}
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// phoneticQuery matches documents with fields sounding like the question
+// in the language. Returns nil if no field is matched phonetically.
+func (ti *TextIndex) phoneticQuery(question string, language string) query.Query {
+	if len(ti.phoneticFields) == 0 {
+		return nil
+	}
+
+	queries := make([]query.Query, len(ti.phoneticFields))
+	for i, field := range ti.phoneticFields {
+		mq := bleve.NewMatchQuery(question)
+		mq.SetField(field)
+		mq.Analyzer = phoneticAnalyzer(language)
+		mq.SetBoost(phoneticBoost)
+		queries[i] = mq
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+// koelnerPhonetik returns the code of a word by the Kölner Phonetik.
+func koelnerPhonetik(word string) string {
+	word = strings.NewReplacer("Ä", "A", "Ö", "O", "Ü", "U", "ß", "S").Replace(strings.ToUpper(word))
+
+	var letters []rune
+	for _, r := range word {
+		if r >= 'A' && r <= 'Z' {
+			letters = append(letters, r)
+		}
+	}
+
+	at := func(i int) rune {
+		if i < 0 || i >= len(letters) {
+			return 0
+		}
+		return letters[i]
+	}
+
+	var code []byte
+	last := byte(0)
+	for i, r := range letters {
+		var c string
+		switch r {
+		case 'A', 'E', 'I', 'J', 'O', 'U', 'Y':
+			c = "0"
+		case 'H':
+			// Not coded, but separates equal codes.
+			last = '-'
+			continue
+		case 'B':
+			c = "1"
+		case 'P':
+			c = "1"
+			if at(i+1) == 'H' {
+				c = "3"
+			}
+		case 'D', 'T':
+			c = "2"
+			if strings.ContainsRune("CSZ", at(i+1)) {
+				c = "8"
+			}
+		case 'F', 'V', 'W':
+			c = "3"
+		case 'G', 'K', 'Q':
+			c = "4"
+		case 'C':
+			c = "8"
+			if i == 0 {
+				if strings.ContainsRune("AHKLOQRUX", at(i+1)) {
+					c = "4"
+				}
+			} else if strings.ContainsRune("AHKOQUX", at(i+1)) && !strings.ContainsRune("SZ", at(i-1)) {
+				c = "4"
+			}
+		case 'X':
+			c = "48"
+			if i > 0 && strings.ContainsRune("CKQ", at(i-1)) {
+				c = "8"
+			}
+		case 'L':
+			c = "5"
+		case 'M', 'N':
+			c = "6"
+		case 'R':
+			c = "7"
+		case 'S', 'Z':
+			c = "8"
+		}
+
+		for j := range len(c) {
+			if c[j] != last {
+				code = append(code, c[j])
+			}
+			last = c[j]
+		}
+	}
+
+	// Remove the vowels except at the beginning.
+	result := make([]byte, 0, len(code))
+	for i, c := range code {
+		if c != '0' || i == 0 {
+			result = append(result, c)
+		}
+	}
+	return string(result)
+}
+
+// phoneticFilter replaces the terms of tokens by their phonetic codes.
+type phoneticFilter struct {
+	encode func(string) []string
+}
+
+func (f *phoneticFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	rv := make(analysis.TokenStream, 0, len(input))
+	for _, token := range input {
+		for _, code := range f.encode(string(token.Term)) {
+			t := *token
+			t.Term = []byte(code)
+			rv = append(rv, &t)
+		}
+	}
+	return rv
+}
+
+func koelnerCodes(word string) []string {
+	if code := koelnerPhonetik(word); code != "" {
+		return []string{code}
+	}
+	return nil
+}
+
+func doubleMetaphoneCodes(word string) []string {
+	primary, alternate := doubleMetaphone(word)
+	switch {
+	case primary == "":
+		return nil
+	case alternate == "" || alternate == primary:
+		return []string{primary}
+	}
+	return []string{primary, alternate}
+}
+
+// phoneticAnalyzerConstructor returns a constructor of the phonetic
+// analyzer of a language. German uses the Kölner Phonetik, all other
+// languages Double Metaphone.
+func phoneticAnalyzerConstructor(language string) registry.AnalyzerConstructor {
+	return func(config map[string]interface{}, cache *registry.Cache) (analysis.Analyzer, error) {
+		unicodeTokenizer, err := cache.TokenizerNamed(unicode.Name)
+		if err != nil {
+			return nil, err
+		}
+		lowercaseFilter, err := cache.TokenFilterNamed(lowercase.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		name := doubleMetaphoneFilter
+		if language == "de" {
+			name = koelnerPhoneticFilter
+		}
+		phonetic, err := cache.TokenFilterNamed(name)
+		if err != nil {
+			return nil, err
+		}
+
+		rv := analysis.DefaultAnalyzer{
+			Tokenizer:    unicodeTokenizer,
+			TokenFilters: []analysis.TokenFilter{lowercaseFilter, phonetic},
+		}
+		return &rv, nil
+	}
+}
+
+func init() {
+	registry.RegisterTokenFilter(koelnerPhoneticFilter, func(map[string]interface{}, *registry.Cache) (analysis.TokenFilter, error) {
+		return &phoneticFilter{encode: koelnerCodes}, nil
+	})
+	registry.RegisterTokenFilter(doubleMetaphoneFilter, func(map[string]interface{}, *registry.Cache) (analysis.TokenFilter, error) {
+		return &phoneticFilter{encode: doubleMetaphoneCodes}, nil
+	})
+	for _, language := range languages() {
+		registry.RegisterAnalyzer(phoneticAnalyzer(language), phoneticAnalyzerConstructor(language))
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestKoelnerPhonetik(t *testing.T) {
+	for _, tt := range []struct {
+		word   string
+		expect string
+	}{
+		{"Müller-Lüdenscheidt", "65752682"},
+		{"Wikipedia", "3412"},
+		{"Breschnew", "17863"},
+		{"Meier", "67"},
+		{"Maier", "67"},
+		{"Mayer", "67"},
+		{"Meyer", "67"},
+		{"Schmidt", "862"},
+		{"Schmitt", "862"},
+		{"Christoph", "47823"},
+		{"Xaver", "4837"},
+		{"", ""},
+	} {
+		if got := koelnerPhonetik(tt.word); got != tt.expect {
+			t.Errorf("koelnerPhonetik(%q) = %q, expected %q", tt.word, got, tt.expect)
+		}
+	}
+}
+
+func TestDoubleMetaphone(t *testing.T) {
+	for _, tt := range []struct {
+		word      string
+		primary   string
+		alternate string
+	}{
+		{"Thompson", "TMPS", "TMPS"},
+		{"Smith", "SM0", "XMT"},
+		{"Schmidt", "XMT", "SMT"},
+		{"Meier", "MR", "MR"},
+		{"Meyer", "MR", "MR"},
+		{"Jose", "HS", "HS"},
+		{"Knight", "NT", "NT"},
+		{"Xavier", "SF", "SFR"},
+		{"Caesar", "SSR", "SSR"},
+		{"", "", ""},
+	} {
+		primary, alternate := doubleMetaphone(tt.word)
+		if primary != tt.primary || alternate != tt.alternate {
+			t.Errorf("doubleMetaphone(%q) = %q, %q, expected %q, %q",
+				tt.word, primary, alternate, tt.primary, tt.alternate)
+		}
+	}
+}
+
+func TestPhoneticSearch(t *testing.T) {
+	collections := meta.Collections{
+		"user": {Fields: map[string]*meta.Member{
+			"last_name": {Type: "string", Searchable: true, Phonetic: true},
+		}},
+	}
+
+	docs := map[string]map[string]any{}
+	for i, name := range []string{"Meier", "Maier", "Mayer", "Meyer", "Müller"} {
+		docs["user/"+strconv.Itoa(i+1)] = map[string]any{"last_name": name}
+	}
+	ti := newTestIndex(t, collections, docs)
+
+	q := ti.buildQuery("Meier", nil, nil, Options{IncludeArchived: true})
+	result, err := ti.index.Search(bleve.NewSearchRequest(q))
+	if err != nil {
+		t.Fatalf("searching failed: %v", err)
+	}
+
+	if len(result.Hits) != 4 {
+		t.Fatalf("got %d hits, expected 4: %v", len(result.Hits), result.Hits)
+	}
+	if result.Hits[0].ID != "user/1" {
+		t.Errorf("exact match is ranked %s", result.Hits[0].ID)
+	}
+	for _, hit := range result.Hits[1:] {
+		if hit.ID == "user/5" {
+			t.Errorf("found %s", hit.ID)
+		}
+		if hit.Score >= result.Hits[0].Score {
+			t.Errorf("phonetic match %s is ranked like the exact one", hit.ID)
+		}
+	}
+}
@@ -11,15 +11,19 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/OpenSlides/openslides-search-service/pkg/config"
+	"github.com/OpenSlides/openslides-search-service/pkg/metrics"
+	"github.com/OpenSlides/openslides-search-service/pkg/tracing"
 )
 
 type queryItem struct {
-	q           string
-	meeting     int
-	collections []string
-	fn          func(map[string]Answer, error)
+	// fn is called with the error of updating the index before searching.
+	fn func(ti *TextIndex, err error)
+	// wait is the queue span of the request. It ends when the query
+	// server picks the item up.
+	wait trace.Span
 }
 
 // QueryServer manages incoming queries against the database.
@@ -38,7 +42,7 @@ func NewQueryServer(cfg *config.Config, ti *TextIndex) (*QueryServer, error) {
 	}, nil
 }
 
-// Run starts the query server.
+// Run starts the query server. The text index has to be built before.
 func (qs *QueryServer) Run(ctx context.Context) {
 	ticker := time.NewTicker(qs.cfg.Index.Update)
 	defer ticker.Stop()
@@ -48,38 +52,163 @@ func (qs *QueryServer) Run(ctx context.Context) {
 			log.Info("shutting down query server")
 			return
 		case <-ticker.C:
-			if err := qs.ti.update(); err != nil {
+			if err := qs.ti.update(ctx); err != nil {
 				log.Errorf("updating text index failed: %v\n", err)
 			}
 		case qi := <-qs.queries:
-			// update the database before searching
-			if err := qs.ti.update(); err != nil {
-				qi.fn(nil, err)
-				continue
-			}
-			qi.fn(qs.ti.Search(qi.q, qi.collections, qi.meeting))
+			metrics.QueueDepth.Set(float64(len(qs.queries)))
+			// update the database before searching. The update is shared
+			// by all queued queries, so it must not depend on the request.
+			// Its span is only linked to the queue span of the request.
+			qi.wait.End()
+			qi.fn(qs.ti, qs.ti.update(ctx, trace.Link{SpanContext: qi.wait.SpanContext()}))
 		}
 	}
 }
 
 var errQueryQueueFull = errors.New("query queue full")
 
-// Query searches the database for hits. Returns a list of fqids.
-func (qs *QueryServer) Query(q string, collections []string, meeting int) (answers map[string]Answer, err error) {
+// enqueue passes fn to the query server and waits until it is done.
+// fn gets the context of the request. The kind is used to label the metrics.
+func (qs *QueryServer) enqueue(ctx context.Context, kind string, fn func(ctx context.Context, ti *TextIndex, err error)) error {
+	if !qs.ti.ready() {
+		return notReadyError{}
+	}
+
+	start := time.Now()
+	_, wait := tracing.Tracer().Start(ctx, "query.queue")
 	done := make(chan struct{})
 	select {
 	case qs.queries <- queryItem{
-		q:           q,
-		collections: collections,
-		meeting:     meeting,
-		fn: func(as map[string]Answer, e error) {
-			answers, err = as, e
-			close(done)
+		fn: func(ti *TextIndex, err error) {
+			defer close(done)
+			fn(ctx, ti, err)
 		},
+		wait: wait,
 	}:
 	default:
-		return nil, errQueryQueueFull
+		wait.End()
+		metrics.QueueRejections.Inc()
+		return errQueryQueueFull
 	}
+	metrics.QueueDepth.Set(float64(len(qs.queries)))
 	<-done
+	metrics.QueryDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+	return nil
+}
+
+// Query searches the database for hits. Returns a list of fqids.
+func (qs *QueryServer) Query(ctx context.Context, q string, collections []string, scope Scope, opts Options) (answers map[string]Answer, err error) {
+	if qerr := qs.enqueue(ctx, "search", func(ctx context.Context, ti *TextIndex, uerr error) {
+		if uerr != nil {
+			err = uerr
+			return
+		}
+		answers, err = ti.Search(ctx, q, collections, scope, opts)
+	}); qerr != nil {
+		return nil, qerr
+	}
 	return
 }
+
+// QueryMeetings searches the given meetings and the meetings of the given
+// committees separately. Returns up to size hits per meeting.
+func (qs *QueryServer) QueryMeetings(
+	ctx context.Context,
+	q string,
+	collections []string,
+	meetingIDs []int,
+	committeeIDs []int,
+	size int,
+	opts Options,
+) (results map[int]MeetingAnswers, err error) {
+	if qerr := qs.enqueue(ctx, "meetings", func(ctx context.Context, ti *TextIndex, uerr error) {
+		if uerr != nil {
+			err = uerr
+			return
+		}
+		results, err = ti.SearchMeetings(ctx, q, collections, meetingIDs, committeeIDs, size, opts)
+	}); qerr != nil {
+		return nil, qerr
+	}
+	return
+}
+
+// Similar finds the documents most similar to an object or a draft within
+// its meeting.
+func (qs *QueryServer) Similar(ctx context.Context, sq SimilarQuery) (answers map[string]Answer, err error) {
+	if qerr := qs.enqueue(ctx, "similar", func(ctx context.Context, ti *TextIndex, uerr error) {
+		if uerr != nil {
+			err = uerr
+			return
+		}
+		answers, err = ti.Similar(ctx, sq)
+	}); qerr != nil {
+		return nil, qerr
+	}
+	return
+}
+
+// Suggest proposes keywords and related objects for an object.
+func (qs *QueryServer) Suggest(ctx context.Context, fqid string, size int) (suggestions *Suggestions, err error) {
+	if qerr := qs.enqueue(ctx, "suggest", func(ctx context.Context, ti *TextIndex, uerr error) {
+		if uerr != nil {
+			err = uerr
+			return
+		}
+		suggestions, err = ti.Suggest(ctx, fqid, size)
+	}); qerr != nil {
+		return nil, qerr
+	}
+	return
+}
+
+// Stats returns statistics about the text index.
+func (qs *QueryServer) Stats(ctx context.Context) (stats *IndexStats, err error) {
+	if qerr := qs.enqueue(ctx, "admin", func(ctx context.Context, ti *TextIndex, uerr error) {
+		if uerr != nil {
+			err = uerr
+			return
+		}
+		stats, err = ti.stats(ctx)
+	}); qerr != nil {
+		return nil, qerr
+	}
+	return
+}
+
+// Inspect returns the indexed document of the given fqid.
+// Returns nil if the document is not indexed.
+func (qs *QueryServer) Inspect(ctx context.Context, fqid string) (info *DocumentInfo, err error) {
+	if qerr := qs.enqueue(ctx, "admin", func(_ context.Context, ti *TextIndex, uerr error) {
+		if uerr != nil {
+			err = uerr
+			return
+		}
+		info, err = ti.inspect(fqid)
+	}); qerr != nil {
+		return nil, qerr
+	}
+	return
+}
+
+// Analyze runs an analyzer on the text. If field is given as
+// collection/field the analyzer bound to it in the language is used.
+// Only looking up the analyzer of a field is queued, as it reads the
+// language of the organization which is changed by updates.
+func (qs *QueryServer) Analyze(ctx context.Context, analyzer, field, language, text string) (string, []Token, error) {
+	if field != "" {
+		var err error
+		if qerr := qs.enqueue(ctx, "admin", func(_ context.Context, ti *TextIndex, _ error) {
+			// The analyzers do not depend on an up to date index.
+			analyzer, err = ti.FieldAnalyzer(field, language)
+		}); qerr != nil {
+			return "", nil, qerr
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	tokens, err := qs.ti.Analyze(analyzer, text)
+	return analyzer, tokens, err
+}
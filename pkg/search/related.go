@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+// relatedField is a field holding texts of related objects.
+type relatedField struct {
+	name    string
+	related *meta.Related
+}
+
+// collectionAt returns the collection of the objects reached after the
+// given number of steps from an object of the root collection.
+func (f *relatedField) collectionAt(root string, step int) string {
+	if step == 0 {
+		return root
+	}
+	return f.related.Path[step-1].Collection
+}
+
+// relatedStore keeps the values of the objects needed to fill the related
+// fields. It tracks which documents depend on an object, so they are
+// re-indexed when it changes.
+type relatedStore struct {
+	// fields are the related fields per root collection.
+	fields map[string][]*relatedField
+	// keep are the fields stored per collection.
+	keep map[string]map[string]struct{}
+	// objects holds the stored values per collection and id.
+	objects map[string]map[int]map[string]any
+	// refs maps the ids held by a stored relation field back to the
+	// objects holding them.
+	refs map[string]map[string]map[int][]int
+}
+
+// newRelatedStore creates the store for the related fields of the
+// collections. Returns nil if there are none.
+func newRelatedStore(collections meta.Collections) *relatedStore {
+	s := &relatedStore{
+		fields:  map[string][]*relatedField{},
+		keep:    map[string]map[string]struct{}{},
+		objects: map[string]map[int]map[string]any{},
+		refs:    map[string]map[string]map[int][]int{},
+	}
+	keep := func(col, field string) {
+		if s.keep[col] == nil {
+			s.keep[col] = map[string]struct{}{}
+		}
+		s.keep[col][field] = struct{}{}
+	}
+
+	for root, col := range collections {
+		for fname, member := range col.Fields {
+			if member.Related == nil {
+				continue
+			}
+			f := &relatedField{name: fname, related: member.Related}
+			s.fields[root] = append(s.fields[root], f)
+
+			for i, step := range f.related.Path {
+				if step.Via != "" {
+					keep(f.collectionAt(root, i), step.Via)
+				} else {
+					keep(step.Collection, step.Back)
+				}
+			}
+			leaf := f.collectionAt(root, len(f.related.Path))
+			for _, field := range f.related.Fields {
+				keep(leaf, field)
+			}
+		}
+	}
+
+	if len(s.fields) == 0 {
+		return nil
+	}
+	return s
+}
+
+// hasRelated tells if documents of the collection have related fields.
+func (s *relatedStore) hasRelated(col string) bool {
+	return s != nil && len(s.fields[col]) > 0
+}
+
+// track stores the needed values of a database row. It returns the fqids
+// of the documents depending on the object before and after the change.
+func (s *relatedStore) track(evt updateEventType, col string, id int, data map[string]any) []string {
+	if s == nil || s.keep[col] == nil {
+		return nil
+	}
+
+	dependents := s.dependents(col, id)
+	s.set(evt, col, id, data)
+	for _, fqid := range s.dependents(col, id) {
+		if !slices.Contains(dependents, fqid) {
+			dependents = append(dependents, fqid)
+		}
+	}
+	return dependents
+}
+
+// set stores the needed values of a database row.
+func (s *relatedStore) set(evt updateEventType, col string, id int, data map[string]any) {
+	if s == nil || s.keep[col] == nil {
+		return
+	}
+	s.remove(col, id)
+	if evt != removeEvent {
+		s.add(col, id, data)
+	}
+}
+
+func (s *relatedStore) add(col string, id int, data map[string]any) {
+	values := map[string]any{}
+	for field := range s.keep[col] {
+		v, ok := data[field]
+		if !ok || v == nil {
+			continue
+		}
+		values[field] = v
+		if s.refs[col] == nil {
+			s.refs[col] = map[string]map[int][]int{}
+		}
+		for _, ref := range relationIDs(v) {
+			if s.refs[col][field] == nil {
+				s.refs[col][field] = map[int][]int{}
+			}
+			s.refs[col][field][ref] = append(s.refs[col][field][ref], id)
+		}
+	}
+	if s.objects[col] == nil {
+		s.objects[col] = map[int]map[string]any{}
+	}
+	s.objects[col][id] = values
+}
+
+func (s *relatedStore) remove(col string, id int) {
+	values, ok := s.objects[col][id]
+	if !ok {
+		return
+	}
+	for field, v := range values {
+		for _, ref := range relationIDs(v) {
+			ids := slices.DeleteFunc(s.refs[col][field][ref], func(other int) bool { return other == id })
+			if len(ids) == 0 {
+				delete(s.refs[col][field], ref)
+			} else {
+				s.refs[col][field][ref] = ids
+			}
+		}
+	}
+	delete(s.objects[col], id)
+}
+
+// fill sets the related fields of a document of the collection.
+func (s *relatedStore) fill(col string, id int, data map[string]any) {
+	if !s.hasRelated(col) {
+		return
+	}
+
+	for _, f := range s.fields[col] {
+		ids := []int{id}
+		for i, step := range f.related.Path {
+			ids = s.follow(f.collectionAt(col, i), step, ids)
+		}
+
+		var texts []string
+		leaf := f.collectionAt(col, len(f.related.Path))
+		for _, rid := range ids {
+			for _, field := range f.related.Fields {
+				if text, ok := s.objects[leaf][rid][field].(string); ok && text != "" {
+					texts = append(texts, text)
+				}
+			}
+		}
+		data[f.name] = texts
+	}
+}
+
+// follow returns the ids of the objects reached by the step from the
+// objects of the collection.
+func (s *relatedStore) follow(col string, step *meta.RelationStep, ids []int) []int {
+	var next []int
+	for _, id := range ids {
+		if step.Via != "" {
+			next = append(next, relationIDs(s.objects[col][id][step.Via])...)
+		} else {
+			next = append(next, s.refs[step.Collection][step.Back][id]...)
+		}
+	}
+	slices.Sort(next)
+	return slices.Compact(next)
+}
+
+// dependents returns the fqids of the documents having the object in one
+// of their related fields.
+func (s *relatedStore) dependents(col string, id int) []string {
+	var fqids []string
+	for root, fields := range s.fields {
+		for _, f := range fields {
+			// The root itself is indexed anyway.
+			for level := len(f.related.Path); level > 0; level-- {
+				if f.collectionAt(root, level) != col {
+					continue
+				}
+
+				ids := []int{id}
+				for i := level; i > 0 && len(ids) > 0; i-- {
+					ids = s.back(f.collectionAt(root, i-1), f.related.Path[i-1], ids)
+				}
+				for _, rid := range ids {
+					fqid := root + "/" + strconv.Itoa(rid)
+					if !slices.Contains(fqids, fqid) {
+						fqids = append(fqids, fqid)
+					}
+				}
+			}
+		}
+	}
+	return fqids
+}
+
+// back returns the ids of the objects of the collection from which the
+// step leads to the given objects.
+func (s *relatedStore) back(col string, step *meta.RelationStep, ids []int) []int {
+	var prev []int
+	for _, id := range ids {
+		if step.Via != "" {
+			prev = append(prev, s.refs[col][step.Via][id]...)
+		} else {
+			prev = append(prev, relationIDs(s.objects[step.Collection][id][step.Back])...)
+		}
+	}
+	slices.Sort(prev)
+	return slices.Compact(prev)
+}
+
+// relationIDs returns the ids held by a relation or relation list value.
+func relationIDs(v any) []int {
+	if id, ok := toInt(v); ok {
+		return []int{int(id)}
+	}
+	values, ok := toSlice(v)
+	if !ok {
+		return nil
+	}
+	ids := make([]int, 0, len(values))
+	for _, value := range values {
+		if id, ok := toInt(value); ok {
+			ids = append(ids, int(id))
+		}
+	}
+	return ids
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestRelated(t *testing.T) {
+	collections := meta.Collections{
+		"motion": {Fields: map[string]*meta.Member{
+			"title": {Type: "string", Searchable: true},
+		}},
+	}
+	filters := meta.Filters{{
+		Name: "motion",
+		Related: map[string]*meta.Related{
+			"submitter_names": {
+				Path: []*meta.RelationStep{
+					{Collection: "motion_submitter", Back: "motion_id"},
+					{Collection: "meeting_user", Via: "meeting_user_id"},
+					{Collection: "user", Via: "user_id"},
+				},
+				Fields: []string{"first_name", "last_name"},
+			},
+			"tag_names": {
+				Path:   []*meta.RelationStep{{Collection: "tag", Via: "tag_ids"}},
+				Fields: []string{"name"},
+			},
+		},
+	}}
+	if err := collections.AddRelated(filters); err != nil {
+		t.Fatalf("adding related fields failed: %v", err)
+	}
+
+	store := newRelatedStore(collections)
+	for _, row := range []struct {
+		col  string
+		id   int
+		data map[string]any
+	}{
+		{"motion", 1, map[string]any{"title": "Haushalt", "tag_ids": []any{int32(1), int32(2)}}},
+		{"motion", 2, map[string]any{"title": "Satzung"}},
+		{"motion_submitter", 1, map[string]any{"motion_id": int32(1), "meeting_user_id": int32(1)}},
+		{"motion_submitter", 2, map[string]any{"motion_id": int32(2), "meeting_user_id": int32(2)}},
+		{"meeting_user", 1, map[string]any{"user_id": int32(1)}},
+		{"meeting_user", 2, map[string]any{"user_id": int32(2)}},
+		{"user", 1, map[string]any{"first_name": "Erika", "last_name": "Mustermann"}},
+		{"user", 2, map[string]any{"first_name": "Max", "last_name": "Meier"}},
+		{"tag", 1, map[string]any{"name": "Finanzen"}},
+		{"tag", 2, map[string]any{"name": "Bildung"}},
+	} {
+		store.set(addedEvent, row.col, row.id, row.data)
+	}
+
+	data := map[string]any{}
+	store.fill("motion", 1, data)
+	if got := data["submitter_names"]; !slices.Equal(got.([]string), []string{"Erika", "Mustermann"}) {
+		t.Errorf("got submitter names %v", got)
+	}
+	if got := data["tag_names"]; !slices.Equal(got.([]string), []string{"Finanzen", "Bildung"}) {
+		t.Errorf("got tag names %v", got)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		col    string
+		id     int
+		data   map[string]any
+		expect []string
+	}{
+		{"user renamed", "user", 1, map[string]any{"first_name": "Erika", "last_name": "Musterfrau"}, []string{"motion/1"}},
+		{"tag renamed", "tag", 2, map[string]any{"name": "Schule"}, []string{"motion/1"}},
+		{"submitter moved", "motion_submitter", 2, map[string]any{"motion_id": int32(1), "meeting_user_id": int32(2)}, []string{"motion/1", "motion/2"}},
+		{"unrelated", "topic", 1, map[string]any{"title": "x"}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := store.track(changedEvent, tt.col, tt.id, tt.data)
+			slices.Sort(got)
+			if !slices.Equal(got, tt.expect) {
+				t.Errorf("got dependents %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+
+	if got := store.track(removeEvent, "user", 2, nil); !slices.Equal(got, []string{"motion/1"}) {
+		t.Errorf("removing user returned dependents %v", got)
+	}
+
+	ti := newTestIndex(t, collections, nil)
+	ti.related = store
+	indexTestDocs(t, ti, map[string]map[string]any{
+		"motion/1": {},
+		"motion/2": {},
+	})
+
+	for _, question := range []string{"Musterfrau", "Schule"} {
+		q := bleve.NewMatchQuery(question)
+		q.Analyzer = textAnalyzer(defaultLanguage)
+		if got := matchingDocs(t, ti, q); !slices.Equal(got, []string{"motion/1"}) {
+			t.Errorf("searching %q found %v, expected motion/1", question, got)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Scope restricts a search to the objects belonging to the given ids.
+// The keys are scope kinds like meeting, committee or organization.
+// Objects within any of the given ids are matched.
+type Scope map[string][]int
+
+// Add appends ids of a scope kind. Ids lower than one are ignored.
+func (s Scope) Add(kind string, ids ...int) {
+	for _, id := range ids {
+		if id > 0 {
+			s[kind] = append(s[kind], id)
+		}
+	}
+}
+
+// scopeField is a field linking an object to a scope.
+type scopeField struct {
+	name string
+	typ  string
+}
+
+// buildScopeFields collects the fields of all collections per scope kind.
+func buildScopeFields(collections meta.Collections) map[string][]scopeField {
+	seen := map[string]map[scopeField]struct{}{}
+	for _, col := range collections {
+		for fname, f := range col.Fields {
+			for _, kind := range f.Scopes {
+				if seen[kind] == nil {
+					seen[kind] = map[scopeField]struct{}{}
+				}
+				seen[kind][scopeField{name: fname, typ: f.Type}] = struct{}{}
+			}
+		}
+	}
+
+	fields := make(map[string][]scopeField, len(seen))
+	for kind, sfs := range seen {
+		fields[kind] = slices.SortedFunc(maps.Keys(sfs), func(a, b scopeField) int {
+			return cmp.Or(cmp.Compare(a.name, b.name), cmp.Compare(a.typ, b.typ))
+		})
+	}
+	return fields
+}
+
+// scopeQuery returns a query matching all documents within the given scope.
+// If the scope does not restrict anything nil is returned.
+func (ti *TextIndex) scopeQuery(scope Scope) query.Query {
+	var queries []query.Query
+	restricted := false
+	for _, kind := range slices.Sorted(maps.Keys(scope)) {
+		for _, id := range scope[kind] {
+			if id <= 0 {
+				continue
+			}
+			restricted = true
+			for _, sf := range ti.scopeFields[kind] {
+				switch sf.typ {
+				case "generic-relation":
+					tq := bleve.NewTermQuery(kind + "/" + strconv.Itoa(id))
+					tq.SetField(sf.name)
+					queries = append(queries, tq)
+				default:
+					nq := newNumericQuery(float64(id))
+					nq.SetField(sf.name)
+					queries = append(queries, nq)
+				}
+			}
+		}
+	}
+
+	if !restricted {
+		return nil
+	}
+	if len(queries) == 0 {
+		return bleve.NewMatchNoneQuery()
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestScopeQuery(t *testing.T) {
+	collections := meta.Collections{
+		"user": {Fields: map[string]*meta.Member{
+			"username":      {Type: "string", Searchable: true},
+			"meeting_ids":   {Type: "relation-list", Scopes: []string{meta.ScopeMeeting}},
+			"committee_ids": {Type: "relation-list", Scopes: []string{meta.ScopeCommittee}},
+		}},
+		"mediafile": {Fields: map[string]*meta.Member{
+			"title":    {Type: "string", Searchable: true},
+			"owner_id": {Type: "generic-relation", Scopes: []string{meta.ScopeMeeting, meta.ScopeOrganization}},
+		}},
+		"motion": {Fields: map[string]*meta.Member{
+			"title":      {Type: "string", Searchable: true},
+			"meeting_id": {Type: "relation", Scopes: []string{meta.ScopeMeeting}},
+		}},
+	}
+
+	ti := newTestIndex(t, collections, map[string]map[string]any{
+		"user/1":      {"username": "anna", "meeting_ids": []any{1}, "committee_ids": []any{2}},
+		"user/2":      {"username": "bert", "committee_ids": []any{2, 3}},
+		"user/3":      {"username": "clara", "meeting_ids": []any{1}},
+		"mediafile/1": {"title": "Logo", "owner_id": "organization/1"},
+		"mediafile/2": {"title": "Minutes", "owner_id": "meeting/1"},
+		"motion/1":    {"title": "Budget", "meeting_id": 1},
+		"motion/2":    {"title": "Statute", "meeting_id": 2},
+	})
+
+	for _, tt := range []struct {
+		name   string
+		scope  Scope
+		expect []string
+	}{
+		{
+			name:   "meeting",
+			scope:  Scope{meta.ScopeMeeting: {1}},
+			expect: []string{"mediafile/2", "motion/1", "user/1", "user/3"},
+		},
+		{
+			name:   "committee",
+			scope:  Scope{meta.ScopeCommittee: {3}},
+			expect: []string{"user/2"},
+		},
+		{
+			name:   "organization",
+			scope:  Scope{meta.ScopeOrganization: {1}},
+			expect: []string{"mediafile/1"},
+		},
+		{
+			name:   "other organization",
+			scope:  Scope{meta.ScopeOrganization: {2}},
+			expect: nil,
+		},
+		{
+			name:   "kinds are ORed",
+			scope:  Scope{meta.ScopeMeeting: {2}, meta.ScopeCommittee: {2}, meta.ScopeOrganization: {1}},
+			expect: []string{"mediafile/1", "motion/2", "user/1", "user/2"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchingDocs(t, ti, ti.scopeQuery(tt.scope))
+			if !slices.Equal(got, tt.expect) {
+				t.Errorf("got %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+
+	if q := ti.scopeQuery(Scope{meta.ScopeMeeting: {0}}); q != nil {
+		t.Errorf("scope without valid ids restricts with %v", q)
+	}
+	if got := matchingDocs(t, ti, bleve.NewConjunctionQuery(
+		ti.scopeQuery(Scope{meta.ScopeCommittee: {2}}),
+		bleve.NewMatchQuery("anna"),
+	)); !slices.Equal(got, []string{"user/1"}) {
+		t.Errorf("got %v for anna in committee 2, expected [user/1]", got)
+	}
+}
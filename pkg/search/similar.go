@@ -0,0 +1,410 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	bleveHtml "github.com/blevesearch/bleve/v2/analysis/char/html"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	index "github.com/blevesearch/bleve_index_api"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+const (
+	// similarText stores the words of the compared texts of a document.
+	similarText = "_similar_text"
+	// similarSignature stores the meeting and the MinHash signature of a
+	// document.
+	similarSignature = "_similar_signature"
+	// similarBands holds the locality sensitive hashes of the signature.
+	// Documents sharing one of them are candidates to be similar.
+	similarBands = "_similar_bands"
+
+	// shingleSize is the number of words compared at once.
+	shingleSize = 3
+	// signatureBands and bandRows split the signature into the hashes
+	// candidates are looked up by. Two rows per band find most documents
+	// sharing a third of their shingles.
+	signatureBands = 32
+	bandRows       = 2
+	signatureSize  = signatureBands * bandRows
+
+	// maxPassages limits the overlapping passages returned per document.
+	maxPassages = 3
+	// similarCandidates limits the candidates compared per request.
+	similarCandidates = 200
+)
+
+// SimilarQuery describes the object to find similar ones to. Either FQID
+// names an indexed object or Text is a draft within the meeting.
+type SimilarQuery struct {
+	FQID        string
+	Text        string
+	MeetingID   int
+	Collections []string
+	Size        int
+	// MinSimilarity leaves out documents sharing fewer shingles.
+	MinSimilarity float64
+}
+
+// UnknownObjectError reports an object which is not indexed for similarity
+// searches.
+type UnknownObjectError struct {
+	FQID string
+}
+
+func (e UnknownObjectError) Error() string {
+	return fmt.Sprintf("%q has no texts to compare", e.FQID)
+}
+
+// Type marks the error as caused by the request.
+func (e UnknownObjectError) Type() string {
+	return "invalid_request"
+}
+
+// signature is the MinHash signature of a text. Each value is the minimum
+// of one hash function over the shingles, so the share of equal values
+// estimates the Jaccard similarity of two texts.
+type signature [signatureSize]uint64
+
+var signatureSeeds = func() (seeds signature) {
+	for i := range seeds {
+		seeds[i] = mix64(uint64(i + 1))
+	}
+	return seeds
+}()
+
+// mix64 is the finalizer of splitmix64.
+func mix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// textWords splits a text into words. Html tags are removed.
+func textWords(text string) []string {
+	text = string(bleveHtml.New().Filter([]byte(text)))
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// shingles returns the overlapping word sequences of the words. Texts
+// shorter than a shingle are one shingle.
+func shingles(words []string) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	if len(words) < shingleSize {
+		return []string{strings.ToLower(strings.Join(words, " "))}
+	}
+	result := make([]string, 0, len(words)-shingleSize+1)
+	for i := 0; i+shingleSize <= len(words); i++ {
+		result = append(result, strings.ToLower(strings.Join(words[i:i+shingleSize], " ")))
+	}
+	return result
+}
+
+// minHash computes the signature of the shingles.
+func minHash(shingles []string) signature {
+	var sig signature
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for _, s := range shingles {
+		h := fnv.New64a()
+		h.Write([]byte(s))
+		x := h.Sum64()
+		for i := range sig {
+			sig[i] = min(sig[i], mix64(x^signatureSeeds[i]))
+		}
+	}
+	return sig
+}
+
+// similarity estimates the Jaccard similarity of the shingles of two
+// signatures.
+func (s signature) similarity(other signature) float64 {
+	equal := 0
+	for i := range s {
+		if s[i] == other[i] {
+			equal++
+		}
+	}
+	return float64(equal) / signatureSize
+}
+
+// bands returns the terms the signature is looked up by. They contain the
+// meeting, so only documents of the same meeting are candidates.
+func (s signature) bands(meetingID int) []string {
+	terms := make([]string, signatureBands)
+	buf := make([]byte, 8*bandRows)
+	for b := range terms {
+		for r := range bandRows {
+			binary.BigEndian.PutUint64(buf[8*r:], s[b*bandRows+r])
+		}
+		h := fnv.New64a()
+		h.Write(buf)
+		terms[b] = fmt.Sprintf("%d:%d:%x", meetingID, b, h.Sum64())
+	}
+	return terms
+}
+
+// encodeSignature stores the meeting and the signature as text.
+func encodeSignature(meetingID int, sig signature) string {
+	buf := make([]byte, 8*signatureSize)
+	for i, v := range sig {
+		binary.BigEndian.PutUint64(buf[8*i:], v)
+	}
+	return strconv.Itoa(meetingID) + ":" + hex.EncodeToString(buf)
+}
+
+// decodeSignature is the counterpart of encodeSignature.
+func decodeSignature(s string) (int, signature, bool) {
+	var sig signature
+	m, h, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, sig, false
+	}
+	meetingID, err := strconv.Atoi(m)
+	if err != nil {
+		return 0, sig, false
+	}
+	buf, err := hex.DecodeString(h)
+	if err != nil || len(buf) != 8*signatureSize {
+		return 0, sig, false
+	}
+	for i := range sig {
+		sig[i] = binary.BigEndian.Uint64(buf[8*i:])
+	}
+	return meetingID, sig, true
+}
+
+// similarFields returns the names of the compared fields in the order of
+// the model.
+func similarFields(fields map[string]*meta.Member) []string {
+	var names []string
+	for fname, field := range fields {
+		if field.Similar {
+			names = append(names, fname)
+		}
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(fields[a].Order, fields[b].Order), strings.Compare(a, b))
+	})
+	return names
+}
+
+// fillSimilar computes the signature of the compared texts of a document
+// of the meeting.
+func (bt bleveType) fillSimilar(fields map[string]*meta.Member, meetingID int) {
+	var words []string
+	for _, fname := range similarFields(fields) {
+		switch v := bt[fname].(type) {
+		case string:
+			words = append(words, textWords(v)...)
+		case []string:
+			for _, s := range v {
+				words = append(words, textWords(s)...)
+			}
+		}
+	}
+	if len(words) == 0 {
+		return
+	}
+
+	sig := minHash(shingles(words))
+	bt[similarText] = strings.Join(words, " ")
+	bt[similarSignature] = encodeSignature(meetingID, sig)
+	bt[similarBands] = sig.bands(meetingID)
+}
+
+// addSimilarFieldMappings maps the fields of similarity searches if the
+// collection has compared texts.
+func addSimilarFieldMappings(docMapping *mapping.DocumentMapping, fields map[string]*meta.Member) {
+	if len(similarFields(fields)) == 0 {
+		return
+	}
+
+	stored := &mapping.FieldMapping{Type: "text", Index: false, Store: true}
+	docMapping.AddFieldMappingsAt(similarText, stored)
+	docMapping.AddFieldMappingsAt(similarSignature, stored)
+
+	bands := bleve.NewTextFieldMapping()
+	bands.Analyzer = keyword.Name
+	bands.Store = false
+	bands.IncludeInAll = false
+	bands.IncludeTermVectors = false
+	docMapping.AddFieldMappingsAt(similarBands, bands)
+
+	for fname, field := range fields {
+		if field.Similar && !field.Searchable && len(field.Scopes) == 0 && !field.Date && !field.Sortable {
+			// Only the signature is needed.
+			docMapping.AddFieldMappingsAt(fname, &mapping.FieldMapping{Type: "text", Index: false})
+		}
+	}
+}
+
+// storedSimilarity loads the words and the signature of an indexed
+// document.
+func (ti *TextIndex) storedSimilarity(fqid string) (string, int, signature, error) {
+	doc, err := ti.index.Document(fqid)
+	if err != nil {
+		return "", 0, signature{}, fmt.Errorf("loading document %q failed: %w", fqid, err)
+	}
+	if doc == nil {
+		return "", 0, signature{}, UnknownObjectError{FQID: fqid}
+	}
+
+	var text, encoded string
+	doc.VisitFields(func(field index.Field) {
+		switch field.Name() {
+		case similarText:
+			text = string(field.Value())
+		case similarSignature:
+			encoded = string(field.Value())
+		}
+	})
+	meetingID, sig, ok := decodeSignature(encoded)
+	if !ok {
+		return "", 0, signature{}, UnknownObjectError{FQID: fqid}
+	}
+	return text, meetingID, sig, nil
+}
+
+// Similar finds the documents of the meeting sharing the most word
+// sequences with an indexed object or a draft. The score of an answer is
+// the estimated share of common sequences. The passages are the longest
+// common parts.
+func (ti *TextIndex) Similar(ctx context.Context, sq SimilarQuery) (map[string]Answer, error) {
+	text := sq.Text
+	meetingID := sq.MeetingID
+	var sig signature
+	if sq.FQID != "" {
+		var err error
+		if text, meetingID, sig, err = ti.storedSimilarity(sq.FQID); err != nil {
+			return nil, err
+		}
+	}
+
+	words := textWords(text)
+	if len(words) == 0 {
+		return map[string]Answer{}, nil
+	}
+	if sq.FQID == "" {
+		sig = minHash(shingles(words))
+	}
+
+	bands := sig.bands(meetingID)
+	candidates := make([]query.Query, len(bands))
+	for i, band := range bands {
+		tq := bleve.NewTermQuery(band)
+		tq.SetField(similarBands)
+		candidates[i] = tq
+	}
+	queries := []query.Query{bleve.NewDisjunctionQuery(candidates...)}
+	if len(sq.Collections) > 0 {
+		queries = append(queries, collectionsQuery(sq.Collections))
+	}
+
+	request := bleve.NewSearchRequest(bleve.NewConjunctionQuery(queries...))
+	request.Size = similarCandidates
+	request.Fields = []string{similarText, similarSignature}
+	result, err := ti.index.SearchInContext(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	type match struct {
+		fqid       string
+		similarity float64
+		text       string
+	}
+	var matches []match
+	for _, hit := range result.Hits {
+		if hit.ID == sq.FQID {
+			continue
+		}
+		encoded, _ := hit.Fields[similarSignature].(string)
+		_, other, ok := decodeSignature(encoded)
+		if !ok {
+			continue
+		}
+		similarity := sig.similarity(other)
+		if similarity < sq.MinSimilarity || similarity == 0 {
+			continue
+		}
+		text, _ := hit.Fields[similarText].(string)
+		matches = append(matches, match{fqid: hit.ID, similarity: similarity, text: text})
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(cmp.Compare(b.similarity, a.similarity), strings.Compare(a.fqid, b.fqid))
+	})
+	if sq.Size > 0 && len(matches) > sq.Size {
+		matches = matches[:sq.Size]
+	}
+
+	answers := make(map[string]Answer, len(matches))
+	for i, m := range matches {
+		answers[m.fqid] = Answer{
+			Rank:     i + 1,
+			Score:    m.similarity,
+			Passages: passages(words, strings.Fields(m.text)),
+		}
+	}
+	return answers, nil
+}
+
+// passages returns the longest word sequences of the text which also
+// appear in the source, in the order of the text.
+func passages(source, text []string) []string {
+	if len(source) < shingleSize || len(text) < shingleSize {
+		return nil
+	}
+	known := map[string]struct{}{}
+	for _, s := range shingles(source) {
+		known[s] = struct{}{}
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	for i, s := range shingles(text) {
+		if _, ok := known[s]; !ok {
+			continue
+		}
+		if n := len(spans); n > 0 && spans[n-1].end >= i+shingleSize-1 {
+			spans[n-1].end = i + shingleSize
+			continue
+		}
+		spans = append(spans, span{start: i, end: i + shingleSize})
+	}
+
+	slices.SortStableFunc(spans, func(a, b span) int {
+		return cmp.Compare(b.end-b.start, a.end-a.start)
+	})
+	spans = spans[:min(len(spans), maxPassages)]
+	slices.SortFunc(spans, func(a, b span) int { return cmp.Compare(a.start, b.start) })
+
+	result := make([]string, len(spans))
+	for i, s := range spans {
+		result[i] = strings.Join(text[s.start:s.end], " ")
+	}
+	return result
+}
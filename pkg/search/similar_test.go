@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestSimilar(t *testing.T) {
+	collections := meta.Collections{
+		"motion": {Fields: map[string]*meta.Member{
+			"title":      {Type: "string", Searchable: true, Similar: true, Order: 1},
+			"text":       {Type: "HTMLStrict", Searchable: true, Similar: true, Order: 2},
+			"meeting_id": {Type: "relation", Scopes: []string{meta.ScopeMeeting}},
+		}},
+	}
+
+	budget := "<p>The city council shall increase the budget of the public library by ten percent next year.</p>"
+	ti := newTestIndex(t, collections, map[string]map[string]any{
+		"motion/1": {"title": "Library budget", "text": budget, "meeting_id": int32(1)},
+		"motion/2": {"title": "Amendment to library budget", "text": budget + "<p>The opening hours are extended on Sundays.</p>", "meeting_id": int32(1)},
+		"motion/3": {"title": "Parking", "text": "<p>More parking spaces are built next to the station.</p>", "meeting_id": int32(1)},
+		"motion/4": {"title": "Library budget", "text": budget, "meeting_id": int32(2)},
+	})
+
+	answers, err := ti.Similar(context.Background(), SimilarQuery{FQID: "motion/1", MinSimilarity: 0.1})
+	if err != nil {
+		t.Fatalf("searching similar failed: %v", err)
+	}
+	if len(answers) != 1 {
+		t.Fatalf("found %v, expected only motion/2 of the same meeting", answers)
+	}
+	amendment, ok := answers["motion/2"]
+	if !ok {
+		t.Fatalf("found %v, expected motion/2", answers)
+	}
+	if amendment.Score < 0.5 || amendment.Score >= 1 {
+		t.Errorf("motion/2 has similarity %f", amendment.Score)
+	}
+	expect := []string{"library budget The city council shall increase the budget of the public library by ten percent next year"}
+	if !slices.Equal(amendment.Passages, expect) {
+		t.Errorf("got passages %q, expected %q", amendment.Passages, expect)
+	}
+
+	answers, err = ti.Similar(context.Background(), SimilarQuery{
+		Text:          "Library budget\nThe city council shall increase the budget of the public library by ten percent.",
+		MeetingID:     1,
+		MinSimilarity: 0.1,
+	})
+	if err != nil {
+		t.Fatalf("searching similar to draft failed: %v", err)
+	}
+	if answers["motion/1"].Rank != 1 || answers["motion/2"].Rank != 2 {
+		t.Errorf("draft found %v, expected motion/1 before motion/2", answers)
+	}
+	if _, ok := answers["motion/3"]; ok {
+		t.Errorf("draft found unrelated motion/3")
+	}
+
+	var unknown UnknownObjectError
+	if _, err := ti.Similar(context.Background(), SimilarQuery{FQID: "motion/9"}); !errors.As(err, &unknown) {
+		t.Errorf("unknown object returned %v, expected UnknownObjectError", err)
+	}
+}
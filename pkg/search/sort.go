@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/registry"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+// sortPrefix prefixes the shadow fields search results are sorted by.
+const sortPrefix = "_sort_"
+
+// sortAnalyzer keeps a text as one lower cased term to sort by.
+const sortAnalyzer = "sort"
+
+// SortField is a field search results are sorted by.
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// InvalidSortError reports a field search results can not be sorted by.
+type InvalidSortError struct {
+	Field string
+}
+
+func (e InvalidSortError) Error() string {
+	return fmt.Sprintf("can not sort by %q", e.Field)
+}
+
+// Type marks the error as caused by the request.
+func (e InvalidSortError) Type() string {
+	return "invalid_request"
+}
+
+// isSortable tells if search results can be sorted by a field.
+func isSortable(field *meta.Member) bool {
+	if !field.Sortable {
+		return false
+	}
+	switch baseType(field.Type) {
+	case "string", "text", "number", "relation", "float", "decimal", "timestamp", "date":
+		return true
+	}
+	return false
+}
+
+// fillSort copies the value of a sortable field into its shadow field.
+func (bt bleveType) fillSort(fname string, field *meta.Member, v any) {
+	if isSortable(field) {
+		bt[sortPrefix+fname] = v
+	}
+}
+
+// addSortFieldMapping adds the shadow field of a sortable field to the
+// document mapping. Texts are sorted case insensitive.
+func addSortFieldMapping(docMapping *mapping.DocumentMapping, fname string, field *meta.Member) {
+	if !field.Sortable {
+		return
+	}
+	if !isSortable(field) {
+		log.Errorf("unsupported type %q on sort field %s\n", field.Type, fname)
+		return
+	}
+
+	var fm *mapping.FieldMapping
+	switch baseType(field.Type) {
+	case "string", "text":
+		fm = bleve.NewTextFieldMapping()
+		fm.Analyzer = sortAnalyzer
+		fm.IncludeTermVectors = false
+	case "timestamp", "date":
+		fm = bleve.NewDateTimeFieldMapping()
+	default:
+		fm = bleve.NewNumericFieldMapping()
+	}
+	fm.Store = false
+	fm.IncludeInAll = false
+	docMapping.AddFieldMappingsAt(sortPrefix+fname, fm)
+
+	if !field.Searchable && len(field.Scopes) == 0 && !field.Date && field.Suggests == "" {
+		// Only the shadow field is needed.
+		docMapping.AddFieldMappingsAt(fname, &mapping.FieldMapping{Type: fm.Type, Index: false})
+	}
+}
+
+// sortOrder returns the order of the search results. The score and the
+// document id break ties, so the order is stable. Returns nil if sorting
+// by relevance.
+func (ti *TextIndex) sortOrder(collections []string, fields []SortField) (bsearch.SortOrder, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	if len(collections) == 0 {
+		for col := range ti.collections {
+			collections = append(collections, col)
+		}
+	}
+
+	order := make(bsearch.SortOrder, 0, len(fields)+2)
+	for _, f := range fields {
+		sortable := false
+		for _, col := range collections {
+			if c, ok := ti.collections[col]; ok && c.Fields[f.Field] != nil && isSortable(c.Fields[f.Field]) {
+				sortable = true
+				break
+			}
+		}
+		if !sortable {
+			return nil, InvalidSortError{Field: f.Field}
+		}
+
+		order = append(order, &bsearch.SortField{
+			Field:   sortPrefix + f.Field,
+			Desc:    f.Descending,
+			Missing: bsearch.SortFieldMissingLast,
+		})
+	}
+	return append(order, &bsearch.SortScore{Desc: true}, &bsearch.SortDocID{}), nil
+}
+
+func sortAnalyzerConstructor(
+	config map[string]interface{},
+	cache *registry.Cache,
+) (analysis.Analyzer, error) {
+	singleTokenizer, err := cache.TokenizerNamed(single.Name)
+	if err != nil {
+		return nil, err
+	}
+	lowercaseFilter, err := cache.TokenFilterNamed(lowercase.Name)
+	if err != nil {
+		return nil, err
+	}
+	rv := analysis.DefaultAnalyzer{
+		Tokenizer:    singleTokenizer,
+		TokenFilters: []analysis.TokenFilter{lowercaseFilter},
+	}
+	return &rv, nil
+}
+
+func init() {
+	registry.RegisterAnalyzer(sortAnalyzer, sortAnalyzerConstructor)
+}
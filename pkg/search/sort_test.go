@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestSort(t *testing.T) {
+	collections := meta.Collections{
+		"motion": {Fields: map[string]*meta.Member{
+			"title":             {Type: "string", Searchable: true, Sortable: true},
+			"text":              {Type: "HTMLStrict", Searchable: true},
+			"sequential_number": {Type: "number", Sortable: true},
+			"created":           {Type: "timestamp", Sortable: true},
+		}},
+	}
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ti := newTestIndex(t, collections, map[string]map[string]any{
+		"motion/1": {"title": "budget", "sequential_number": int32(3), "created": created},
+		"motion/2": {"title": "Antrag zum Budget", "text": "Budget Budget", "sequential_number": int32(1), "created": created.AddDate(0, 2, 0)},
+		"motion/3": {"title": "Zuschuss", "text": "Budget", "sequential_number": int32(2)},
+		"motion/4": {"title": "Budget", "sequential_number": int32(10), "created": created.AddDate(0, 1, 0)},
+	})
+
+	for _, tt := range []struct {
+		name   string
+		sort   []SortField
+		expect []string
+	}{
+		{"number", []SortField{{Field: "sequential_number"}}, []string{"motion/2", "motion/3", "motion/1", "motion/4"}},
+		{"number descending", []SortField{{Field: "sequential_number", Descending: true}}, []string{"motion/4", "motion/1", "motion/3", "motion/2"}},
+		{"title", []SortField{{Field: "title"}}, []string{"motion/2", "motion/1", "motion/4", "motion/3"}},
+		{"missing last", []SortField{{Field: "created", Descending: true}}, []string{"motion/2", "motion/4", "motion/1", "motion/3"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			answers, err := ti.Search(context.Background(), "budget", nil, nil, Options{Sort: tt.sort})
+			if err != nil {
+				t.Fatalf("searching failed: %v", err)
+			}
+
+			got := make([]string, len(answers))
+			for fqid, answer := range answers {
+				got[answer.Rank-1] = fqid
+			}
+			if !slices.Equal(got, tt.expect) {
+				t.Errorf("got %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+
+	// Equal titles are ordered by relevance and then by id.
+	order, err := ti.sortOrder(nil, []SortField{{Field: "title"}})
+	if err != nil {
+		t.Fatalf("sort order failed: %v", err)
+	}
+	if len(order) != 3 {
+		t.Errorf("got sort order %v, expected title, score and id", order)
+	}
+
+	var sortErr InvalidSortError
+	if _, err := ti.Search(context.Background(), "budget", nil, nil, Options{Sort: []SortField{{Field: "text"}}}); !errors.As(err, &sortErr) {
+		t.Errorf("sorting by text returned %v, expected InvalidSortError", err)
+	}
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// States of the text index.
+const (
+	IndexBuilding = "building"
+	IndexReady    = "ready"
+	IndexFailed   = "failed"
+)
+
+// IndexStatus describes the state of the text index.
+type IndexStatus struct {
+	State string
+	// Documents is the number of indexed documents.
+	Documents uint64
+	// LastUpdate is the time of the last successful update.
+	LastUpdate time.Time
+	// Lag is the age of the oldest change processed by the last update.
+	Lag time.Duration
+}
+
+// indexStatus guards the status of the text index which is written by the
+// query server and read by the health checks.
+type indexStatus struct {
+	mu     sync.RWMutex
+	status IndexStatus
+}
+
+func (s *indexStatus) get() IndexStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+func (s *indexStatus) set(fn func(*IndexStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.status)
+}
+
+// notReadyError is returned for queries while the index is built.
+type notReadyError struct{}
+
+func (notReadyError) Error() string {
+	return "the search index is not ready yet"
+}
+
+func (notReadyError) Type() string {
+	return "not_ready"
+}
+
+func (notReadyError) StatusCode() int {
+	return http.StatusServiceUnavailable
+}
@@ -0,0 +1,298 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"html"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2"
+	bleveHtml "github.com/blevesearch/bleve/v2/analysis/char/html"
+	"github.com/blevesearch/bleve/v2/mapping"
+	index "github.com/blevesearch/bleve_index_api"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+const (
+	// suggestSimilar is the number of similar objects whose relations are
+	// suggested.
+	suggestSimilar = 20
+	// suggestMinSimilarity leaves out objects sharing hardly any text.
+	suggestMinSimilarity = 0.05
+	// minKeywordLength leaves out short words as keywords.
+	minKeywordLength = 3
+)
+
+// Suggestions are proposed keywords and related objects for an object.
+type Suggestions struct {
+	Keywords []Keyword         `json:"keywords"`
+	Objects  []SuggestedObject `json:"objects"`
+}
+
+// Keyword is a distinctive word of an object. Term is the indexed form
+// of the word.
+type Keyword struct {
+	Word  string  `json:"word"`
+	Term  string  `json:"term"`
+	Score float64 `json:"score"`
+}
+
+// SuggestedObject is an object related to similar objects, like a tag or
+// a category. Its score is the sum of the similarities of the objects it
+// was found at.
+type SuggestedObject struct {
+	FQID  string  `json:"fqid"`
+	Field string  `json:"field"`
+	Score float64 `json:"score"`
+	// From maps the similar objects to their similarity.
+	From map[string]float64 `json:"from"`
+}
+
+// addSuggestFieldMappings stores the relation fields whose objects are
+// suggested. Searchable and scope fields are stored anyway.
+func addSuggestFieldMappings(docMapping *mapping.DocumentMapping, fields map[string]*meta.Member) {
+	for fname, field := range fields {
+		if field.Suggests == "" || field.Searchable || len(field.Scopes) > 0 {
+			continue
+		}
+		fm := bleve.NewNumericFieldMapping()
+		fm.Index = false
+		fm.IncludeInAll = false
+		fm.DocValues = false
+		docMapping.AddFieldMappingsAt(fname, fm)
+	}
+}
+
+// Suggest proposes the most distinctive words of an indexed object and the
+// objects related to similar objects which it is not related to itself.
+func (ti *TextIndex) Suggest(ctx context.Context, fqid string, size int) (*Suggestions, error) {
+	doc, err := ti.index.Document(fqid)
+	if err != nil {
+		return nil, err
+	}
+	col, _, _ := strings.Cut(fqid, "/")
+	mcol, ok := ti.collections[col]
+	if doc == nil || !ok {
+		return nil, UnknownObjectError{FQID: fqid}
+	}
+
+	stored := map[string][]any{}
+	doc.VisitFields(func(field index.Field) {
+		value, _ := storedValue(field)
+		stored[field.Name()] = append(stored[field.Name()], value)
+	})
+	language := defaultLanguage
+	if values := stored[languageField]; len(values) > 0 {
+		language, _ = values[0].(string)
+	}
+
+	keywords, err := ti.keywords(ctx, col, language, mcol.Fields, stored)
+	if err != nil {
+		return nil, err
+	}
+	suggestions := &Suggestions{
+		Keywords: keywords[:min(size, len(keywords))],
+		Objects:  []SuggestedObject{},
+	}
+
+	if !hasSuggestFields(mcol.Fields) {
+		return suggestions, nil
+	}
+
+	similar, err := ti.Similar(ctx, SimilarQuery{
+		FQID:          fqid,
+		Collections:   []string{col},
+		Size:          suggestSimilar,
+		MinSimilarity: suggestMinSimilarity,
+	})
+	var unknown UnknownObjectError
+	if errors.As(err, &unknown) {
+		// Without texts to compare only keywords are suggested.
+		return suggestions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	own := relatedFQIDs(mcol.Fields, stored)
+	objects := map[string]*SuggestedObject{}
+	for _, other := range slices.Sorted(maps.Keys(similar)) {
+		otherDoc, err := ti.index.Document(other)
+		if err != nil {
+			return nil, err
+		}
+		if otherDoc == nil {
+			continue
+		}
+		otherStored := map[string][]any{}
+		otherDoc.VisitFields(func(field index.Field) {
+			value, _ := storedValue(field)
+			otherStored[field.Name()] = append(otherStored[field.Name()], value)
+		})
+
+		for related, field := range relatedFQIDs(mcol.Fields, otherStored) {
+			if _, ok := own[related]; ok {
+				continue
+			}
+			if objects[related] == nil {
+				objects[related] = &SuggestedObject{FQID: related, Field: field, From: map[string]float64{}}
+			}
+			objects[related].From[other] = similar[other].Score
+			objects[related].Score += similar[other].Score
+		}
+	}
+
+	for _, object := range objects {
+		suggestions.Objects = append(suggestions.Objects, *object)
+	}
+	SortSuggestedObjects(suggestions.Objects)
+	suggestions.Objects = suggestions.Objects[:min(size, len(suggestions.Objects))]
+	return suggestions, nil
+}
+
+// SortSuggestedObjects orders suggested objects by their score.
+func SortSuggestedObjects(objects []SuggestedObject) {
+	slices.SortFunc(objects, func(a, b SuggestedObject) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), strings.Compare(a.FQID, b.FQID))
+	})
+}
+
+// hasSuggestFields tells if objects of relation fields are suggested.
+func hasSuggestFields(fields map[string]*meta.Member) bool {
+	for _, field := range fields {
+		if field.Suggests != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// relatedFQIDs returns the fqids of the suggested objects held by the
+// stored fields together with the field holding them.
+func relatedFQIDs(fields map[string]*meta.Member, stored map[string][]any) map[string]string {
+	fqids := map[string]string{}
+	for fname, field := range fields {
+		if field.Suggests == "" {
+			continue
+		}
+		for _, v := range stored[fname] {
+			if id, ok := v.(float64); ok && id > 0 {
+				fqids[field.Suggests+"/"+strconv.Itoa(int(id))] = fname
+			}
+		}
+	}
+	return fqids
+}
+
+// keywords ranks the words of the text fields of a document by tf-idf.
+// The document frequencies are taken from the index, so words used in
+// many documents are not distinctive.
+func (ti *TextIndex) keywords(ctx context.Context, col, language string, fields map[string]*meta.Member, stored map[string][]any) ([]Keyword, error) {
+	advanced, err := ti.index.Advanced()
+	if err != nil {
+		return nil, err
+	}
+	reader, err := advanced.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	total, err := reader.DocCount()
+	if err != nil {
+		return nil, err
+	}
+
+	scores := map[string]float64{}
+	words := map[string]map[string]int{}
+	for _, fname := range slices.Sorted(maps.Keys(fields)) {
+		field := fields[fname]
+		if !field.Searchable {
+			continue
+		}
+		isHTML := false
+		switch baseType(field.Type) {
+		case "string", "text":
+		case "HTMLStrict", "HTMLPermissive":
+			isHTML = true
+		default:
+			continue
+		}
+
+		analyzer, err := ti.FieldAnalyzer(col+"/"+fname, language)
+		if err != nil {
+			continue
+		}
+
+		frequencies := map[string]int{}
+		for _, v := range stored[fname] {
+			text, ok := v.(string)
+			if !ok {
+				continue
+			}
+			if isHTML {
+				// Clean up first, so the offsets point into the text.
+				text = html.UnescapeString(string(bleveHtml.New().Filter([]byte(text))))
+			}
+			tokens, err := ti.Analyze(analyzer, text)
+			if err != nil {
+				return nil, err
+			}
+			for _, token := range tokens {
+				if token.Type == "numeric" || utf8.RuneCountInString(token.Term) < minKeywordLength {
+					continue
+				}
+				frequencies[token.Term]++
+				if token.Start >= 0 && token.End <= len(text) && token.Start < token.End {
+					if words[token.Term] == nil {
+						words[token.Term] = map[string]int{}
+					}
+					words[token.Term][strings.ToLower(text[token.Start:token.End])]++
+				}
+			}
+		}
+
+		for term, tf := range frequencies {
+			tfr, err := reader.TermFieldReader(ctx, []byte(term), fname, false, false, false)
+			if err != nil {
+				return nil, err
+			}
+			df := tfr.Count()
+			if err := tfr.Close(); err != nil {
+				return nil, err
+			}
+			if df == 0 {
+				continue
+			}
+			scores[term] += math.Sqrt(float64(tf)) * math.Log(1+float64(total)/float64(df))
+		}
+	}
+
+	keywords := make([]Keyword, 0, len(scores))
+	for term, score := range scores {
+		keyword := Keyword{Word: term, Term: term, Score: score}
+		// The most frequent spelling is shown.
+		best := 0
+		for _, word := range slices.Sorted(maps.Keys(words[term])) {
+			if words[term][word] > best {
+				keyword.Word, best = word, words[term][word]
+			}
+		}
+		keywords = append(keywords, keyword)
+	}
+	slices.SortFunc(keywords, func(a, b Keyword) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), strings.Compare(a.Term, b.Term))
+	})
+	return keywords, nil
+}
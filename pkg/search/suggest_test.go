@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+func TestSuggest(t *testing.T) {
+	collections := meta.Collections{
+		"motion": {Fields: map[string]*meta.Member{
+			"title":       {Type: "string", Searchable: true, Similar: true, Order: 1},
+			"text":        {Type: "HTMLStrict", Searchable: true, Similar: true, Order: 2},
+			"meeting_id":  {Type: "relation", Scopes: []string{meta.ScopeMeeting}},
+			"category_id": {Type: "relation", Suggests: "motion_category"},
+			"tag_ids":     {Type: "relation-list", Suggests: "tag"},
+		}},
+	}
+
+	library := "<p>The council shall increase the budget of the public Library. Libraries need books.</p>"
+	ti := newTestIndex(t, collections, map[string]map[string]any{
+		"motion/1": {"title": "Library budget", "text": library, "meeting_id": int32(1), "tag_ids": []any{int32(7)}},
+		"motion/2": {"title": "More library budget", "text": library, "meeting_id": int32(1), "category_id": int32(3), "tag_ids": []any{int32(5), int32(7)}},
+		"motion/3": {"title": "Parking", "text": "<p>The council shall build parking spaces.</p>", "meeting_id": int32(1), "tag_ids": []any{int32(9)}},
+		"motion/4": {"title": "Streets", "text": "<p>The council shall repair the streets.</p>", "meeting_id": int32(1)},
+	})
+
+	suggestions, err := ti.Suggest(context.Background(), "motion/1", 10)
+	if err != nil {
+		t.Fatalf("suggesting failed: %v", err)
+	}
+
+	rank := func(word string) int {
+		return slices.IndexFunc(suggestions.Keywords, func(k Keyword) bool { return k.Word == word })
+	}
+	if rank("library") == -1 || rank("council") == -1 || rank("library") > rank("council") {
+		t.Errorf("got keywords %v, expected library before council", suggestions.Keywords)
+	}
+
+	var got []string
+	for _, object := range suggestions.Objects {
+		got = append(got, object.FQID)
+		if object.From["motion/2"] == 0 {
+			t.Errorf("%s is not suggested by motion/2: %v", object.FQID, object.From)
+		}
+	}
+	slices.Sort(got)
+	if expect := []string{"motion_category/3", "tag/5"}; !slices.Equal(got, expect) {
+		t.Errorf("got suggested objects %v, expected %v", got, expect)
+	}
+
+	if _, err := ti.Suggest(context.Background(), "motion/9", 10); err == nil {
+		t.Errorf("suggesting for an unknown object did not fail")
+	}
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Synonyms expands the words and phrases of a question with their synonyms
+// and abbreviations.
+type Synonyms struct {
+	// expansions maps a normalized phrase to its alternatives.
+	expansions map[string][]string
+	// maxWords is the number of words of the longest phrase.
+	maxWords int
+}
+
+// LoadSynonyms reads a synonyms file.
+func LoadSynonyms(file string) (*Synonyms, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("opening synonyms file failed: %w", err)
+	}
+	defer f.Close()
+	return ParseSynonyms(f)
+}
+
+// ParseSynonyms parses synonyms line by line. A line either lists
+// equivalent phrases separated by commas or maps phrases to their
+// replacements with "=>". Empty lines and lines starting with # are
+// ignored.
+//
+//	GO, Geschäftsordnung
+//	TOP => Tagesordnungspunkt
+func ParseSynonyms(r io.Reader) (*Synonyms, error) {
+	s := &Synonyms{expansions: map[string][]string{}}
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		from, to, oneWay := strings.Cut(line, "=>")
+		sources := splitPhrases(from)
+		targets := sources
+		if oneWay {
+			targets = splitPhrases(to)
+		}
+		if len(sources) == 0 || len(targets) == 0 || (!oneWay && len(sources) < 2) {
+			return nil, fmt.Errorf("synonyms line %d: invalid entry %q", lineNo, line)
+		}
+
+		for _, source := range sources {
+			key := strings.Join(normalizeWords(source), " ")
+			for _, target := range targets {
+				if strings.Join(normalizeWords(target), " ") == key {
+					continue
+				}
+				if !slices.Contains(s.expansions[key], target) {
+					s.expansions[key] = append(s.expansions[key], target)
+				}
+			}
+			s.maxWords = max(s.maxWords, len(normalizeWords(source)))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading synonyms failed: %w", err)
+	}
+	return s, nil
+}
+
+// splitPhrases splits a comma separated list of phrases.
+func splitPhrases(s string) []string {
+	var phrases []string
+	for phrase := range strings.SplitSeq(s, ",") {
+		if phrase = strings.Join(strings.Fields(phrase), " "); phrase != "" {
+			phrases = append(phrases, phrase)
+		}
+	}
+	return phrases
+}
+
+// normalizeWords splits a text into lower case words.
+func normalizeWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Expand returns the alternatives of all phrases found in the question.
+// Longer phrases are preferred over the words they contain.
+func (s *Synonyms) Expand(question string) []string {
+	if s == nil {
+		return nil
+	}
+
+	var alternatives []string
+	words := normalizeWords(question)
+	for i := 0; i < len(words); {
+		matched := 0
+		for n := min(s.maxWords, len(words)-i); n > 0; n-- {
+			if expansions, ok := s.expansions[strings.Join(words[i:i+n], " ")]; ok {
+				for _, e := range expansions {
+					if !slices.Contains(alternatives, e) {
+						alternatives = append(alternatives, e)
+					}
+				}
+				matched = n
+				break
+			}
+		}
+		i += max(matched, 1)
+	}
+	return alternatives
+}
+
+// synonymsQuery returns a query matching any of the alternatives as phrase
+// analyzed by the given analyzer. Returns nil if there are no alternatives.
+func synonymsQuery(alternatives []string, analyzer string) query.Query {
+	if len(alternatives) == 0 {
+		return nil
+	}
+	queries := make([]query.Query, len(alternatives))
+	for i, alternative := range alternatives {
+		phraseQuery := bleve.NewMatchPhraseQuery(alternative)
+		phraseQuery.Analyzer = analyzer
+		queries[i] = phraseQuery
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+// SetSynonyms replaces the synonyms used by the following searches.
+func (ti *TextIndex) SetSynonyms(synonyms *Synonyms) {
+	ti.synonyms.Store(synonyms)
+}
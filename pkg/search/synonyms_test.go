@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSynonymsExpand(t *testing.T) {
+	synonyms, err := ParseSynonyms(strings.NewReader(`
+# abbreviations
+GO, Geschäftsordnung
+Antrag, Motion
+TOP => Tagesordnungspunkt
+Änderung der Geschäftsordnung, GO-Änderung
+`))
+	if err != nil {
+		t.Fatalf("parsing synonyms failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		question string
+		expect   []string
+	}{
+		{"go", []string{"Geschäftsordnung"}},
+		{"Geschäftsordnung", []string{"GO"}},
+		{"Antrag zum TOP", []string{"Motion", "Tagesordnungspunkt"}},
+		{"Tagesordnungspunkt", nil},
+		{"Änderung der Geschäftsordnung", []string{"GO-Änderung"}},
+		{"GO-Änderung", []string{"Änderung der Geschäftsordnung"}},
+		{"Haushalt", nil},
+	} {
+		t.Run(tt.question, func(t *testing.T) {
+			if got := synonyms.Expand(tt.question); !slices.Equal(got, tt.expect) {
+				t.Errorf("Expand(%q) = %v, expected %v", tt.question, got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestSynonymsInvalid(t *testing.T) {
+	if _, err := ParseSynonyms(strings.NewReader("GO")); err == nil {
+		t.Errorf("expected an error for a single phrase")
+	}
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package search
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+)
+
+// newTestIndex creates an in memory text index of the collections with the
+// documents given by fqid. The index is closed when the test ends.
+func newTestIndex(tb testing.TB, collections meta.Collections, docs map[string]map[string]any) *TextIndex {
+	tb.Helper()
+
+	im, err := buildIndexMapping(collections, nil, nil)
+	if err != nil {
+		tb.Fatalf("building mapping failed: %v", err)
+	}
+	return newTestIndexWithMapping(tb, im, collections, docs)
+}
+
+// newTestIndexWithMapping is like newTestIndex but uses the given index
+// mapping.
+func newTestIndexWithMapping(
+	tb testing.TB,
+	im mapping.IndexMapping,
+	collections meta.Collections,
+	docs map[string]map[string]any,
+) *TextIndex {
+	tb.Helper()
+
+	index, err := bleve.NewMemOnly(im)
+	if err != nil {
+		tb.Fatalf("creating index failed: %v", err)
+	}
+	tb.Cleanup(func() { index.Close() })
+
+	ti := &TextIndex{
+		index:                index,
+		indexMapping:         im,
+		collections:          collections,
+		scopeFields:          buildScopeFields(collections),
+		ngramFields:          buildNgramFields(collections),
+		phoneticFields:       buildPhoneticFields(collections),
+		identifierFields:     buildIdentifierFields(collections),
+		related:              newRelatedStore(collections),
+		organizationLanguage: defaultLanguage,
+	}
+	indexTestDocs(tb, ti, docs)
+	return ti
+}
+
+// indexTestDocs adds or replaces the documents given by fqid.
+func indexTestDocs(tb testing.TB, ti *TextIndex, docs map[string]map[string]any) {
+	tb.Helper()
+
+	batch := ti.index.NewBatch()
+	for _, fqid := range slices.Sorted(maps.Keys(docs)) {
+		col, rawID, _ := strings.Cut(fqid, "/")
+		id, err := strconv.Atoi(rawID)
+		if err != nil {
+			tb.Fatalf("invalid fqid %q", fqid)
+		}
+		if err := batch.Index(fqid, ti.document(col, id, ti.collections[col], docs[fqid])); err != nil {
+			tb.Fatalf("indexing %s failed: %v", fqid, err)
+		}
+	}
+	if err := ti.index.Batch(batch); err != nil {
+		tb.Fatalf("indexing failed: %v", err)
+	}
+}
+
+// matchingDocs returns the sorted ids of all documents matching the query.
+func matchingDocs(tb testing.TB, ti *TextIndex, q query.Query) []string {
+	tb.Helper()
+
+	request := bleve.NewSearchRequestOptions(q, 1000, 0, false)
+	result, err := ti.index.Search(request)
+	if err != nil {
+		tb.Fatalf("searching failed: %v", err)
+	}
+
+	ids := make([]string, len(result.Hits))
+	for i, hit := range result.Hits {
+		ids[i] = hit.ID
+	}
+	slices.Sort(ids)
+	return ids
+}
@@ -6,17 +6,24 @@ package search
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/OpenSlides/openslides-search-service/pkg/config"
 	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+	"github.com/OpenSlides/openslides-search-service/pkg/metrics"
+	"github.com/OpenSlides/openslides-search-service/pkg/tracing"
 
 	"github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/analysis"
@@ -38,28 +45,88 @@ type TextIndex struct {
 	collections  meta.Collections
 	indexMapping mapping.IndexMapping
 	index        bleve.Index
+	scopeFields  map[string][]scopeField
+	ngramFields  []string
+	// phoneticFields are matched by the sound of the words.
+	phoneticFields []string
+	// identifierFields hold numbers like the one of a motion.
+	identifierFields []string
+	// related keeps the values of the objects the related fields are
+	// filled with.
+	related *relatedStore
+	// meetingStates holds the lifecycle state of every meeting.
+	meetingStates map[int]string
+	// meetingLanguages holds the supported language of every meeting.
+	meetingLanguages     map[int]string
+	organizationLanguage string
+	status               indexStatus
+	// boosts can be replaced while searching.
+	boosts   atomic.Pointer[meta.Boosts]
+	synonyms atomic.Pointer[Synonyms]
 }
 
-// NewTextIndex creates a new text index.
+// NewTextIndex creates a new text index. It has to be filled with Build
+// before it can be searched.
 func NewTextIndex(
 	cfg *config.Config,
 	db *Database,
 	collections meta.Collections,
 ) (*TextIndex, error) {
-	ti := &TextIndex{
-		cfg:          cfg,
-		db:           db,
-		collections:  collections,
-		indexMapping: buildIndexMapping(collections),
+	words, err := decompoundWords(cfg.Models.Decompound)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := ti.build(); err != nil {
-		return nil, err
+	var customAnalysis *meta.Analysis
+	if cfg.Models.Search != "" {
+		if customAnalysis, err = meta.Fetch[*meta.Analysis](cfg.Models.Search); err != nil {
+			return nil, fmt.Errorf("loading custom analysis failed: %w", err)
+		}
 	}
 
+	indexMapping, err := buildIndexMapping(collections, words, customAnalysis)
+	if err != nil {
+		return nil, fmt.Errorf("building index mapping failed: %w", err)
+	}
+
+	ti := &TextIndex{
+		cfg:              cfg,
+		db:               db,
+		collections:      collections,
+		indexMapping:     indexMapping,
+		scopeFields:      buildScopeFields(collections),
+		ngramFields:      buildNgramFields(collections),
+		phoneticFields:   buildPhoneticFields(collections),
+		identifierFields: buildIdentifierFields(collections),
+		related:          newRelatedStore(collections),
+	}
+	ti.status.set(func(s *IndexStatus) { s.State = IndexBuilding })
 	return ti, nil
 }
 
+// Build fills the text index from the database.
+func (ti *TextIndex) Build(ctx context.Context) error {
+	if err := ti.build(ctx); err != nil {
+		ti.status.set(func(s *IndexStatus) { s.State = IndexFailed })
+		return err
+	}
+	ti.status.set(func(s *IndexStatus) {
+		s.State = IndexReady
+		s.LastUpdate = ti.db.last
+	})
+	return nil
+}
+
+// Status returns the current state of the text index.
+func (ti *TextIndex) Status() IndexStatus {
+	return ti.status.get()
+}
+
+// ready tells if the text index can be searched.
+func (ti *TextIndex) ready() bool {
+	return ti.status.get().State == IndexReady
+}
+
 // Close tears down an open text index.
 func (ti *TextIndex) Close() error {
 	if ti == nil {
@@ -76,7 +143,10 @@ func (ti *TextIndex) Close() error {
 	return err1
 }
 
-const deHTML = "de_html"
+const (
+	deHTML = "de_html"
+	deText = "de_text"
+)
 
 func deHTMLAnalyzerConstructor(
 	config map[string]interface{},
@@ -91,38 +161,62 @@ func deHTMLAnalyzerConstructor(
 	if err != nil {
 		return nil, err
 	}
-	toLowerFilter, err := cache.TokenFilterNamed(lowercase.Name)
+	tokenFilters, err := deTokenFilters(cache)
 	if err != nil {
 		return nil, err
 	}
-	stopDeFilter, err := cache.TokenFilterNamed(de.StopName)
-	if err != nil {
-		return nil, err
+	rv := analysis.DefaultAnalyzer{
+		CharFilters: []analysis.CharFilter{
+			htmlFilter,
+			&specialCharFilter{},
+		},
+		Tokenizer:    unicodeTokenizer,
+		TokenFilters: tokenFilters,
 	}
-	normalizeDeFilter, err := cache.TokenFilterNamed(de.NormalizeName)
+	return &rv, nil
+}
+
+// deTextAnalyzerConstructor builds the analyzer of German text. It is
+// bleve's de analyzer with decompounding.
+func deTextAnalyzerConstructor(
+	config map[string]interface{},
+	cache *registry.Cache,
+) (analysis.Analyzer, error) {
+	unicodeTokenizer, err := cache.TokenizerNamed(unicode.Name)
 	if err != nil {
 		return nil, err
 	}
-	lightStemmerDeFilter, err := cache.TokenFilterNamed(de.LightStemmerName)
+	tokenFilters, err := deTokenFilters(cache)
 	if err != nil {
 		return nil, err
 	}
 	rv := analysis.DefaultAnalyzer{
-		CharFilters: []analysis.CharFilter{
-			htmlFilter,
-			&specialCharFilter{},
-		},
-		Tokenizer: unicodeTokenizer,
-		TokenFilters: []analysis.TokenFilter{
-			toLowerFilter,
-			stopDeFilter,
-			normalizeDeFilter,
-			lightStemmerDeFilter,
-		},
+		Tokenizer:    unicodeTokenizer,
+		TokenFilters: tokenFilters,
 	}
 	return &rv, nil
 }
 
+// deTokenFilters returns the token filters of the German analyzers.
+// Compound words are split before the stemming.
+func deTokenFilters(cache *registry.Cache) ([]analysis.TokenFilter, error) {
+	var filters []analysis.TokenFilter
+	for _, name := range []string{
+		lowercase.Name,
+		deDecompound,
+		de.StopName,
+		de.NormalizeName,
+		de.LightStemmerName,
+	} {
+		filter, err := cache.TokenFilterNamed(name)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
 type specialCharFilter struct{}
 
 func (f *specialCharFilter) Filter(input []byte) []byte {
@@ -132,6 +226,7 @@ func (f *specialCharFilter) Filter(input []byte) []byte {
 
 func init() {
 	registry.RegisterAnalyzer(deHTML, deHTMLAnalyzerConstructor)
+	registry.RegisterAnalyzer(deText, deTextAnalyzerConstructor)
 }
 
 type bleveType map[string]any
@@ -140,134 +235,235 @@ func newBleveType(typ string) bleveType {
 	return bleveType{"_bleve_type": typ}
 }
 
+// BleveType returns the name of the document mapping. It depends on the
+// collection and the language of the document.
 func (bt bleveType) BleveType() string {
-	return bt["_bleve_type"].(string)
+	language, _ := bt[languageField].(string)
+	return typeName(bt["_bleve_type"].(string), language)
 }
 
-func buildIndexMapping(collections meta.Collections) mapping.IndexMapping {
+func buildIndexMapping(
+	collections meta.Collections,
+	decompoundWords []any,
+	customAnalysis *meta.Analysis,
+) (mapping.IndexMapping, error) {
 	numberFieldMapping := bleve.NewNumericFieldMapping()
 
 	numberedRelationFieldMapping := bleve.NewNumericFieldMapping()
 	numberedRelationFieldMapping.IncludeInAll = false
 
-	textFieldMapping := bleve.NewTextFieldMapping()
-	textFieldMapping.Analyzer = de.AnalyzerName
-
-	htmlFieldMapping := bleve.NewTextFieldMapping()
-	htmlFieldMapping.Analyzer = deHTML
-
 	collectionInfoFieldMapping := bleve.NewTextFieldMapping()
 	collectionInfoFieldMapping.Analyzer = keyword.Name
 	collectionInfoFieldMapping.IncludeInAll = false
 
+	booleanFieldMapping := bleve.NewBooleanFieldMapping()
+	booleanFieldMapping.IncludeInAll = false
+
+	dateTimeFieldMapping := bleve.NewDateTimeFieldMapping()
+	dateTimeFieldMapping.IncludeInAll = false
+
 	simpleFieldMapping := bleve.NewTextFieldMapping()
 	simpleFieldMapping.Analyzer = simple.Name
 
+	identifierFieldMapping := bleve.NewTextFieldMapping()
+	identifierFieldMapping.Analyzer = identifierAnalyzer
+
+	textFieldMappings := map[string]*mapping.FieldMapping{}
+	htmlFieldMappings := map[string]*mapping.FieldMapping{}
+	for _, language := range languages() {
+		textFieldMappings[language] = bleve.NewTextFieldMapping()
+		textFieldMappings[language].Analyzer = textAnalyzer(language)
+		htmlFieldMappings[language] = bleve.NewTextFieldMapping()
+		htmlFieldMappings[language].Analyzer = htmlAnalyzer(language)
+	}
+
 	indexMapping := mapping.NewIndexMapping()
 	indexMapping.TypeField = "_bleve_type"
 
+	if err := addDecompoundFilter(indexMapping, decompoundWords); err != nil {
+		return nil, err
+	}
+
+	if err := addCustomAnalysis(indexMapping, customAnalysis); err != nil {
+		return nil, err
+	}
+
 	for name, col := range collections {
-		docMapping := bleve.NewDocumentMapping()
-		docMapping.AddFieldMappingsAt("_bleve_type", collectionInfoFieldMapping)
+		// The mappings which do not depend on the language are shared by
+		// the document mappings of all languages.
+		shared := bleve.NewDocumentMapping()
+		shared.AddFieldMappingsAt("_bleve_type", collectionInfoFieldMapping)
+		shared.AddFieldMappingsAt(meetingStateField, collectionInfoFieldMapping)
+		shared.AddFieldMappingsAt(languageField, collectionInfoFieldMapping)
+		addDateFieldMappings(shared, col.Fields)
+		addCollapseFieldMapping(shared, col.Fields)
+		addSimilarFieldMappings(shared, col.Fields)
+		addSuggestFieldMappings(shared, col.Fields)
 		for fname, cf := range col.Fields {
+			addSortFieldMapping(shared, fname, cf)
 			if cf.Searchable {
 				if cf.Analyzer == nil {
-					switch cf.Type {
-					case "HTMLStrict", "HTMLPermissive":
-						docMapping.AddFieldMappingsAt(fname, htmlFieldMapping)
+					switch baseType(cf.Type) {
+					case "HTMLStrict", "HTMLPermissive", "JSON", "json-int-string-map", "string[]":
+						// Mapped per language.
 					case "string", "text":
-						docMapping.AddFieldMappingsAt(fname, textFieldMapping)
-						docMapping.AddFieldMappingsAt("_"+fname+"_original", simpleFieldMapping)
-					case "generic-relation":
-						docMapping.AddFieldMappingsAt(fname, collectionInfoFieldMapping)
+						shared.AddFieldMappingsAt("_"+fname+"_original", simpleFieldMapping)
+					case "generic-relation", "generic-relation-list", "color":
+						shared.AddFieldMappingsAt(fname, collectionInfoFieldMapping)
 					case "relation", "relation-list":
-						docMapping.AddFieldMappingsAt(fname, numberedRelationFieldMapping)
-					case "number", "number[]":
-						docMapping.AddFieldMappingsAt(fname, numberFieldMapping)
+						shared.AddFieldMappingsAt(fname, numberedRelationFieldMapping)
+					case "number", "number[]", "float", "decimal":
+						shared.AddFieldMappingsAt(fname, numberFieldMapping)
+					case "boolean":
+						shared.AddFieldMappingsAt(fname, booleanFieldMapping)
+					case "timestamp", "date":
+						shared.AddFieldMappingsAt(fname, dateTimeFieldMapping)
 					default:
 						log.Errorf("unsupport type %q on field %s\n", cf.Type, fname)
 					}
 				} else {
 					switch *cf.Analyzer {
 					case "html":
-						docMapping.AddFieldMappingsAt(fname, htmlFieldMapping)
+						// Mapped per language.
 					case "simple":
-						docMapping.AddFieldMappingsAt(fname, simpleFieldMapping)
+						shared.AddFieldMappingsAt(fname, simpleFieldMapping)
+					case identifierAnalyzer:
+						shared.AddFieldMappingsAt(fname, identifierFieldMapping)
 					default:
-						log.Errorf("unsupported analyzer %q on field %s\n", *cf.Analyzer, fname)
+						if customAnalysis == nil || customAnalysis.Analyzers[*cf.Analyzer] == nil {
+							return nil, fmt.Errorf("unknown analyzer %q on field %s", *cf.Analyzer, fname)
+						}
+						customFieldMapping := bleve.NewTextFieldMapping()
+						customFieldMapping.Analyzer = *cf.Analyzer
+						shared.AddFieldMappingsAt(fname, customFieldMapping)
+					}
+				}
+			} else if len(cf.Scopes) > 0 {
+				switch cf.Type {
+				case "generic-relation", "generic-relation-list":
+					shared.AddFieldMappingsAt(fname, collectionInfoFieldMapping)
+				case "relation", "relation-list", "number", "number[]":
+					shared.AddFieldMappingsAt(fname, numberedRelationFieldMapping)
+				default:
+					log.Errorf("unsupported type %q on scope field %s\n", cf.Type, fname)
+				}
+			}
+		}
+
+		for _, language := range languages() {
+			// The language fields have their own paths, so adding them
+			// does not change the shared mappings.
+			docMapping := *shared
+			docMapping.Properties = maps.Clone(shared.Properties)
+			for fname, cf := range col.Fields {
+				if !cf.Searchable {
+					continue
+				}
+				addPhoneticFieldMapping(&docMapping, fname, cf, language)
+				addNgramFieldMappings(&docMapping, fname, cf, language)
+				if cf.Analyzer == nil {
+					switch baseType(cf.Type) {
+					case "HTMLStrict", "HTMLPermissive", "JSON", "json-int-string-map":
+						docMapping.AddFieldMappingsAt(fname, htmlFieldMappings[language])
+					case "string", "text", "string[]":
+						docMapping.AddFieldMappingsAt(fname, textFieldMappings[language])
 					}
+				} else if *cf.Analyzer == "html" {
+					docMapping.AddFieldMappingsAt(fname, htmlFieldMappings[language])
 				}
 			}
+			indexMapping.AddDocumentMapping(typeName(name, language), &docMapping)
 		}
-		indexMapping.AddDocumentMapping(name, docMapping)
 	}
 
-	indexMapping.DefaultAnalyzer = de.AnalyzerName
+	indexMapping.DefaultAnalyzer = deText
 
-	return indexMapping
+	return indexMapping, nil
 }
 
 func (bt bleveType) fill(fields map[string]*meta.Member, data map[string]any) {
 	for fname, field := range fields {
-		if !field.Searchable {
+		if !field.Indexed() {
 			continue
 		}
-		switch fields[fname].Type {
-		case "string", "text":
-			if v, ok := data[fname].(string); ok {
-				bt[fname] = v
-				bt["_"+fname+"_original"] = v
-				continue
-			}
-		case "HTMLStrict", "HTMLPermissive", "generic-relation":
-			if v, ok := data[fname].(string); ok {
-				bt[fname] = v
-				continue
-			}
-		case "relation", "number":
-			if v, ok := data[fname].(int32); ok {
-				bt[fname] = v
-				continue
-			} else if v, ok := data[fname].(int64); ok {
-				bt[fname] = v
-				continue
-			} else if v, ok := data[fname].(int); ok {
-				bt[fname] = v
-				continue
-			}
-		case "number[]":
-			bt[fname] = []int64{}
-			if arr, ok := data[fname].([]int64); ok {
-				for _, value := range arr {
-					bt[fname] = append(bt[fname].([]int64), value)
-				}
-			}
-			continue
-		case "json-int-string-map":
-			bt[fname] = []string{}
-			if arr, ok := data[fname].([]string); ok {
-				for _, value := range arr {
-					bt[fname] = append(bt[fname].([]string), value)
-				}
-			}
-			continue
-		default:
-			bt[fname] = data[fname]
+		value := data[fname]
+		if field.Computed != nil {
+			value = computeValue(field.Computed, data)
+		}
+		v, ok := fieldValue(field.Type, value)
+		if !ok {
+			delete(bt, fname)
 			continue
 		}
+		bt[fname] = v
+		if field.Type == "string" || field.Type == "text" {
+			bt["_"+fname+"_original"] = v
+		}
+		bt.fillNgrams(fname, field, v)
+		bt.fillPhonetic(fname, field, v)
+		bt.fillSort(fname, field, v)
+	}
+	bt.fillDates(fields)
+	bt.fillCollapse(fields)
+}
 
-		delete(bt, fname)
+// document creates the index document of a database row.
+func (ti *TextIndex) document(col string, id int, mcol *meta.Collection, data map[string]any) bleveType {
+	bt := newBleveType(col)
+	ti.related.fill(col, id, data)
+	bt.fill(mcol.Fields, data)
+	meetingID := meetingOf(col, id, mcol, data)
+	bt.fillSimilar(mcol.Fields, meetingID)
+	if state, ok := ti.meetingStates[meetingID]; ok {
+		bt[meetingStateField] = state
 	}
+	bt[languageField] = ti.languageOf(col, id, mcol, data)
+	return bt
 }
 
-func (ti *TextIndex) update() error {
+// update applies the changes of the database to the index. The span of the
+// update is linked to the spans of the given requests.
+func (ti *TextIndex) update(ctx context.Context, links ...trace.Link) error {
+	ctx, span := tracing.Tracer().Start(ctx, "index.update", trace.WithLinks(links...))
+	defer span.End()
+
+	start, last := time.Now(), ti.db.last
+	defer func() {
+		// Only count updates which were not skipped.
+		if ti.db.last != last {
+			metrics.IndexUpdateDuration.Observe(time.Since(start).Seconds())
+			metrics.IndexLastUpdate.Set(float64(ti.db.last.Unix()))
+			ti.status.set(func(s *IndexStatus) {
+				s.LastUpdate = ti.db.last
+				s.Lag = ti.db.lag
+			})
+		}
+	}()
 
 	batch, batchCount := ti.index.NewBatch(), 0
+	changed := false
+	changedMeetings := map[int]struct{}{}
+	dependents := map[string]struct{}{}
+	reindexAll := false
 
-	if err := ti.db.update(func(
+	if err := ti.db.update(ctx, func(
 		evt updateEventType,
 		col string, id int, data map[string]any,
 	) error {
+		if ti.trackLanguage(evt, col, id, data) {
+			if col == "organization" {
+				reindexAll = true
+			} else {
+				changedMeetings[id] = struct{}{}
+			}
+		}
+		if col == "meeting" && ti.trackMeeting(evt, id, data) {
+			changedMeetings[id] = struct{}{}
+		}
+		for _, fqid := range ti.related.track(evt, col, id, data) {
+			dependents[fqid] = struct{}{}
+		}
+
 		// we dont care if its not an indexed type.
 		mcol := ti.collections[col]
 		if mcol == nil {
@@ -276,19 +472,16 @@ func (ti *TextIndex) update() error {
 		fqid := col + "/" + strconv.Itoa(id)
 		switch evt {
 		case addedEvent:
-			bt := newBleveType(col)
-			bt.fill(mcol.Fields, data)
-			batch.Index(fqid, bt)
+			batch.Index(fqid, ti.document(col, id, mcol, data))
 
 		case changedEvent:
 			batch.Delete(fqid)
-			bt := newBleveType(col)
-			bt.fill(mcol.Fields, data)
-			batch.Index(fqid, bt)
+			batch.Index(fqid, ti.document(col, id, mcol, data))
 
 		case removeEvent:
 			batch.Delete(fqid)
 		}
+		changed = true
 		if batchCount++; batchCount >= ti.cfg.Index.Batch {
 			if err := ti.index.Batch(batch); err != nil {
 				return err
@@ -306,13 +499,56 @@ func (ti *TextIndex) update() error {
 		}
 	}
 
+	if reindexAll {
+		if err := ti.reindexAll(ctx); err != nil {
+			return err
+		}
+	} else {
+		if len(changedMeetings) > 0 {
+			if err := ti.reindexMeetings(ctx, slices.Collect(maps.Keys(changedMeetings))); err != nil {
+				return err
+			}
+		}
+		if len(dependents) > 0 {
+			if err := ti.reindex(ctx, slices.Collect(maps.Keys(dependents))); err != nil {
+				return fmt.Errorf("re-indexing dependent documents failed: %w", err)
+			}
+		}
+	}
+
+	if changed {
+		ti.updateDocumentMetrics(ctx)
+	}
+
 	return nil
 }
 
-func (ti *TextIndex) build() error {
+// updateDocumentMetrics counts the indexed documents per collection.
+func (ti *TextIndex) updateDocumentMetrics(ctx context.Context) {
+	request := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), 0, 0, false)
+	request.AddFacet("collections", bleve.NewFacetRequest("_bleve_type", len(ti.collections)))
+
+	result, err := ti.index.SearchInContext(ctx, request)
+	if err != nil {
+		log.Errorf("counting documents failed: %v\n", err)
+		return
+	}
+
+	ti.status.set(func(s *IndexStatus) { s.Documents = result.Total })
+
+	metrics.IndexDocuments.Reset()
+	if facet, ok := result.Facets["collections"]; ok {
+		for _, term := range facet.Terms.Terms() {
+			metrics.IndexDocuments.WithLabelValues(term.Term).Set(float64(term.Count))
+		}
+	}
+}
+
+func (ti *TextIndex) build(ctx context.Context) error {
 	start := time.Now()
 	defer func() {
 		log.Infof("building initial text index took %v\n", time.Since(start))
+		metrics.IndexBuildDuration.Set(time.Since(start).Seconds())
 	}()
 
 	// Remove old index file
@@ -328,6 +564,19 @@ func (ti *TextIndex) build() error {
 		}
 	}
 
+	meetingStates, err := ti.db.meetingStates(ctx)
+	if err != nil {
+		return fmt.Errorf("loading meeting states failed: %w", err)
+	}
+	ti.meetingStates = meetingStates
+
+	if ti.organizationLanguage, err = ti.db.organizationLanguage(ctx); err != nil {
+		return fmt.Errorf("loading organization language failed: %w", err)
+	}
+	if ti.meetingLanguages, err = ti.db.meetingLanguages(ctx); err != nil {
+		return fmt.Errorf("loading meeting languages failed: %w", err)
+	}
+
 	index, err := bleve.New(ti.cfg.Index.File, ti.indexMapping)
 	if err != nil {
 		return fmt.Errorf(
@@ -335,39 +584,68 @@ func (ti *TextIndex) build() error {
 	}
 
 	batch, batchCount := index.NewBatch(), 0
+	var documents uint64
 
-	if err := ti.db.fill(func(_ updateEventType, col string, id int, data map[string]any) error {
-		// Dont care for collections which are not text indexed.
-
-		mcol := ti.collections[col]
-		if mcol == nil {
-			return nil
-		}
+	indexDocument := func(col string, id int, mcol *meta.Collection, data map[string]any) error {
 		fqid := col + "/" + strconv.Itoa(id)
-		bt := newBleveType(col)
-		bt.fill(mcol.Fields, data)
-
-		batch.Index(fqid, bt)
+		batch.Index(fqid, ti.document(col, id, mcol, data))
 		if batchCount++; batchCount >= ti.cfg.Index.Batch {
 			if err := index.Batch(batch); err != nil {
 				return fmt.Errorf("writing batch failed: %w", err)
 			}
+			documents += uint64(batchCount)
+			ti.status.set(func(s *IndexStatus) { s.Documents = documents })
 			batch, batchCount = index.NewBatch(), 0
 		}
 		return nil
+	}
+
+	// Documents with related fields are indexed after all related objects
+	// are known.
+	type pendingDocument struct {
+		col  string
+		id   int
+		data map[string]any
+	}
+	var pending []pendingDocument
+
+	ti.related = newRelatedStore(ti.collections)
+	if err := ti.db.fill(ctx, func(evt updateEventType, col string, id int, data map[string]any) error {
+		ti.related.set(evt, col, id, data)
+
+		// Dont care for collections which are not text indexed.
+		mcol := ti.collections[col]
+		if mcol == nil {
+			return nil
+		}
+		if ti.related.hasRelated(col) {
+			pending = append(pending, pendingDocument{col: col, id: id, data: data})
+			return nil
+		}
+		return indexDocument(col, id, mcol, data)
 	}); err != nil {
 		index.Close()
 		return err
 	}
 
+	for _, doc := range pending {
+		if err := indexDocument(doc.col, doc.id, ti.collections[doc.col], doc.data); err != nil {
+			index.Close()
+			return err
+		}
+	}
+
 	if batchCount > 0 {
 		if err := index.Batch(batch); err != nil {
 			index.Close()
 			return fmt.Errorf("writing batch failed: %w", err)
 		}
+		documents += uint64(batchCount)
+		ti.status.set(func(s *IndexStatus) { s.Documents = documents })
 	}
 
 	ti.index = index
+	ti.updateDocumentMetrics(ctx)
 
 	return nil
 }
@@ -382,8 +660,18 @@ func newNumericQuery(num float64) *query.NumericRangeQuery {
 
 // Answer contains additional information of an search results answer
 type Answer struct {
+	// Rank is the position of the answer in the results starting at 1.
+	Rank         int
 	Score        float64
 	MatchedWords map[string][]string
+	// Explanation and Contributions are only set if requested.
+	Explanation   *Explanation       `json:",omitempty"`
+	Contributions map[string]float64 `json:",omitempty"`
+	// Collapsed are the hits folded into the answer if collapsing.
+	Collapsed []CollapsedHit `json:",omitempty"`
+	// Passages are the texts shared with the object of a similarity
+	// search.
+	Passages []string `json:",omitempty"`
 }
 
 func filterExactMatchTerms(question string) string {
@@ -416,69 +704,210 @@ func cleanupQuestion(question string) string {
 	return question
 }
 
+// Options contains optional settings of a search.
+type Options struct {
+	// IncludeArchived also finds objects of archived and template meetings
+	// in searches which are not restricted to explicit meetings.
+	IncludeArchived bool
+	// Explain adds the score explanations to the answers.
+	Explain bool
+	// From and To restrict the search to objects with a timestamp within
+	// the range. Zero times leave the range open.
+	From time.Time
+	To   time.Time
+	// Recency ranks recent objects higher. It is the age halving the
+	// boost. Zero disables the boost.
+	Recency time.Duration
+	// Sort orders the results by the fields instead of their relevance.
+	Sort []SortField
+	// Collapse folds hits of auxiliary objects like agenda items into the
+	// hits of the objects they belong to.
+	Collapse bool
+}
+
 // Search queries the internal index for hits.
-func (ti *TextIndex) Search(question string, collections []string, meetingID int) (map[string]Answer, error) {
+func (ti *TextIndex) Search(ctx context.Context, question string, collections []string, scope Scope, opts Options) (map[string]Answer, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "index.search")
+	defer span.End()
+
 	start := time.Now()
 	defer func() {
 		log.Debugf("searching for %q took %v\n", question, time.Since(start))
 	}()
 
+	order, err := ti.sortOrder(collections, opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	request := bleve.NewSearchRequest(ti.buildQuery(question, collections, scope, opts))
+	request.IncludeLocations = true
+	request.Explain = opts.Explain
+	request.Size = 100
+	if order != nil {
+		request.SortByCustom(order)
+	}
+	if opts.Collapse {
+		request.Fields = []string{collapseField}
+	}
+
+	result, err := ti.index.SearchInContext(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	answers := answersFromResult(result)
+	if opts.Collapse {
+		answers = ti.collapse(answers, result, collections, order != nil)
+	}
+	return answers, nil
+}
+
+// buildQuery creates the query for a question restricted to the given
+// collections and scope.
+func (ti *TextIndex) buildQuery(question string, collections []string, scope Scope, opts Options) query.Query {
 	question = cleanupQuestion(question)
-	wildcardQuestion := bytes.Buffer{}
+	var substrings []string
 	for w := range strings.SplitSeq(filterExactMatchTerms(question), " ") {
 		if len(w) > 2 && w[0] != byte('*') && w[len(w)-1] != byte('*') {
-			wildcardQuestion.WriteString("*" + strings.ToLower(w) + "* ")
+			substrings = append(substrings, w)
 		}
 	}
-	wildcardQuery := bleve.NewQueryStringQuery(wildcardQuestion.String())
+	substringQuery := ti.ngramQuery(substrings)
+
+	// The question is analyzed like the documents it is searched in.
+	languages := ti.queryLanguages(scope)
+
+	matchQueryOriginal := perLanguage(languages, func(language string) query.Query {
+		return originalQuery(question, textAnalyzer(language))
+	})
+
+	fuzzyMatchQuery := perLanguage(languages, func(language string) query.Query {
+		fuzzyMatchQuery := bleve.NewMatchQuery(question)
+		fuzzyMatchQuery.SetAutoFuzziness(true)
+		fuzzyMatchQuery.Analyzer = textAnalyzer(language)
+		return fuzzyMatchQuery
+	})
+
+	matchQuery := bleve.NewDisjunctionQuery(
+		labeled(subQueryOriginal, matchQueryOriginal, opts),
+		labeled(subQuerySubstring, substringQuery, opts),
+		labeled(subQueryFuzzy, fuzzyMatchQuery, opts),
+	)
+
+	if alternatives := ti.synonyms.Load().Expand(question); len(alternatives) > 0 {
+		synonymsQuery := perLanguage(languages, func(language string) query.Query {
+			return synonymsQuery(alternatives, textAnalyzer(language))
+		})
+		matchQuery.AddQuery(labeled(subQuerySynonyms, synonymsQuery, opts))
+	}
 
-	matchQueryOriginal := bleve.NewQueryStringQuery(question)
-	matchQueryOriginal.SetBoost(5)
+	if identifierQuery := ti.identifierQuery(question); identifierQuery != nil {
+		matchQuery.AddQuery(labeled(subQueryIdentifier, identifierQuery, opts))
+	}
 
-	fuzzyMatchQuery := bleve.NewMatchQuery(question)
-	fuzzyMatchQuery.SetAutoFuzziness(true)
+	phoneticQuery := perLanguage(languages, func(language string) query.Query {
+		return ti.phoneticQuery(question, language)
+	})
+	if phoneticQuery != nil {
+		matchQuery.AddQuery(labeled(subQueryPhonetic, phoneticQuery, opts))
+	}
 
-	matchQuery := bleve.NewDisjunctionQuery(matchQueryOriginal, wildcardQuery, fuzzyMatchQuery)
+	var q query.Query = matchQuery
+	boosts := ti.boosts.Load()
+	if boosts != nil {
+		fieldQuery := perLanguage(languages, func(language string) query.Query {
+			return ti.fieldBoostQuery(question, collections, boosts, textAnalyzer(language))
+		})
+		if fieldQuery != nil {
+			bq := bleve.NewBooleanQuery()
+			bq.AddMust(matchQuery)
+			bq.AddShould(labeled(subQueryFieldBoost, fieldQuery, opts))
+			q = bq
+		}
+	}
 
-	var q query.Query
-	if meetingID > 0 {
-		fmid := float64(meetingID)
-		meetingIDQuery := newNumericQuery(fmid)
-		meetingIDQuery.SetField("meeting_id")
+	if scopeQuery := ti.scopeQuery(scope); scopeQuery != nil {
+		q = bleve.NewConjunctionQuery(labeled(subQueryScope, scopeQuery, opts), q)
+	}
 
-		meetingIDsQuery := newNumericQuery(fmid)
-		meetingIDsQuery.SetField("meeting_ids")
+	if len(collections) > 0 {
+		q = bleve.NewConjunctionQuery(q, labeled(subQueryCollections, collectionsQuery(collections), opts))
+	}
 
-		meetingIDOwnerQuery := bleve.NewTermQuery("meeting/" + strconv.Itoa(meetingID))
-		meetingIDOwnerQuery.SetField("owner_id")
+	if dateQuery := dateRangeQuery(opts.From, opts.To); dateQuery != nil {
+		q = bleve.NewConjunctionQuery(q, labeled(subQueryDates, dateQuery, opts))
+	}
 
-		meetingQuery := bleve.NewDisjunctionQuery(meetingIDQuery, meetingIDsQuery, meetingIDOwnerQuery)
-		q = bleve.NewConjunctionQuery(meetingQuery, matchQuery)
-	} else {
-		q = matchQuery
+	if !opts.IncludeArchived && len(scope[meta.ScopeMeeting]) == 0 {
+		q = excludeInactiveMeetings(q)
 	}
 
-	if len(collections) > 0 {
-		collQueries := make([]query.Query, len(collections))
-		for i, c := range collections {
-			collQuery := bleve.NewTermQuery(c)
-			collQuery.SetField("_bleve_type")
-			collQueries[i] = collQuery
-		}
+	if boosts != nil && len(boosts.Collections) > 0 {
+		q = &collectionBoostQuery{boosts: boosts.Collections, query: q}
+	}
 
-		collFilterQuery := bleve.NewDisjunctionQuery(collQueries...)
-		q = bleve.NewConjunctionQuery(q, collFilterQuery)
+	if opts.Recency > 0 {
+		q = &recencyBoostQuery{halfLife: opts.Recency, now: time.Now(), query: q}
 	}
 
-	request := bleve.NewSearchRequest(q)
-	request.IncludeLocations = true
-	request.Size = 100
+	return q
+}
+
+// originalQuery returns the query of the question in the query string
+// syntax analyzed by the given analyzer.
+func originalQuery(question string, analyzer string) query.Query {
+	qsq := bleve.NewQueryStringQuery(question)
+	qsq.SetBoost(5)
+
+	// Invalid questions fail when searching as before.
+	q, err := qsq.Parse()
+	if err != nil {
+		return qsq
+	}
+	// bleve ignores the boost of a query string query. The parsed query is
+	// not boosted either to keep the scores.
+	setAnalyzer(q, analyzer)
+	return q
+}
+
+// collectionsQuery returns a query matching documents of the given collections.
+func collectionsQuery(collections []string) query.Query {
+	collQueries := make([]query.Query, len(collections))
+	for i, c := range collections {
+		collQuery := bleve.NewTermQuery(c)
+		collQuery.SetField("_bleve_type")
+		collQueries[i] = collQuery
+	}
+	return bleve.NewDisjunctionQuery(collQueries...)
+}
 
-	result, err := ti.index.Search(request)
+// matchingIDs returns the ids of all documents matching the query.
+func (ti *TextIndex) matchingIDs(ctx context.Context, q query.Query) ([]string, error) {
+	// Count first to fetch all of them at once.
+	request := bleve.NewSearchRequestOptions(q, 0, 0, false)
+	result, err := ti.index.SearchInContext(ctx, request)
 	if err != nil {
 		return nil, err
 	}
+	if result.Total == 0 {
+		return nil, nil
+	}
+
+	request = bleve.NewSearchRequestOptions(q, int(result.Total), 0, false)
+	if result, err = ti.index.SearchInContext(ctx, request); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(result.Hits))
+	for i, hit := range result.Hits {
+		ids[i] = hit.ID
+	}
+	return ids, nil
+}
 
+// answersFromResult converts the hits of a search result to answers.
+func answersFromResult(result *bleve.SearchResult) map[string]Answer {
 	dupes := map[string]struct{}{}
 	answers := make(map[string]Answer, len(result.Hits))
 	numDupes := 0
@@ -493,21 +922,33 @@ func (ti *TextIndex) Search(question string, collections []string, meetingID int
 
 		matchedWords := map[string][]string{}
 		for location := range result.Hits[i].Locations {
-			matchedWords[location] = []string{}
+			// Matches in n-gram fields are reported for their source field.
+			field := strings.TrimPrefix(location, ngramPrefix)
+			if matchedWords[field] == nil {
+				matchedWords[field] = []string{}
+			}
 			for word := range result.Hits[i].Locations[location] {
-				matchedWords[location] = append(matchedWords[location], word)
+				if !slices.Contains(matchedWords[field], word) {
+					matchedWords[field] = append(matchedWords[field], word)
+				}
 			}
 		}
 
 		dupes[fqid] = struct{}{}
-		answers[fqid] = Answer{
+		answer := Answer{
+			Rank:         len(answers) + 1,
 			Score:        result.Hits[i].Score,
 			MatchedWords: matchedWords,
 		}
+		if expl := result.Hits[i].Expl; expl != nil {
+			answer.Explanation = expl
+			answer.Contributions = contributions(expl)
+		}
+		answers[fqid] = answer
 
 		log.Debugf("Hit %s - %v", fqid, matchedWords)
 	}
 
 	log.Debugf("number of duplicates: %d\n", numDupes)
-	return answers, nil
+	return answers
 }
@@ -59,17 +59,17 @@ func TestUnrestrictedOutput(t *testing.T) {
 			"test",
 			[]string{},
 			map[string]Answer{
-				"topic/2": {2.4873344398209953, map[string][]string{
+				"topic/2": {Score: 2.4873344398209953, MatchedWords: map[string][]string{
 					"_title_original": {"test"},
 					"text":            {"test", "west"},
 					"title":           {"test"},
 				},
 				},
-				"meeting/2": {0.013346666139263209, map[string][]string{
+				"meeting/2": {Score: 0.013346666139263209, MatchedWords: map[string][]string{
 					"welcome_text": {"text"},
 				},
 				},
-				"meeting/1": {0.013346666139263209, map[string][]string{
+				"meeting/1": {Score: 0.013346666139263209, MatchedWords: map[string][]string{
 					"welcome_text": {"text"},
 				},
 				},
@@ -79,19 +79,19 @@ func TestUnrestrictedOutput(t *testing.T) {
 			"test",
 			[]string{"topic", "meeting"},
 			map[string]Answer{
-				"topic/2": {2.5441687942241002, map[string][]string{
+				"topic/2": {Score: 2.5441687942241002, MatchedWords: map[string][]string{
 					"_bleve_type":     {"topic"},
 					"_title_original": {"test"},
 					"text":            {"test", "west"},
 					"title":           {"test"},
 				},
 				},
-				"meeting/2": {0.47219033407422906, map[string][]string{
+				"meeting/2": {Score: 0.47219033407422906, MatchedWords: map[string][]string{
 					"_bleve_type":  {"meeting"},
 					"welcome_text": {"text"},
 				},
 				},
-				"meeting/1": {0.47219033407422906, map[string][]string{
+				"meeting/1": {Score: 0.47219033407422906, MatchedWords: map[string][]string{
 					"_bleve_type":  {"meeting"},
 					"welcome_text": {"text"},
 				},
@@ -102,7 +102,7 @@ func TestUnrestrictedOutput(t *testing.T) {
 			"test",
 			[]string{"topic"},
 			map[string]Answer{
-				"topic/2": {3.2582204751744155, map[string][]string{
+				"topic/2": {Score: 3.2582204751744155, MatchedWords: map[string][]string{
 					"_bleve_type":     {"topic"},
 					"_title_original": {"test"},
 					"text":            {"test", "west"},
@@ -120,7 +120,7 @@ func TestUnrestrictedOutput(t *testing.T) {
 			"teams",
 			[]string{},
 			map[string]Answer{
-				"topic/2": {0.8773653826510427, map[string][]string{
+				"topic/2": {Score: 0.8773653826510427, MatchedWords: map[string][]string{
 					"text": {"team"},
 				},
 				},
@@ -138,7 +138,7 @@ func TestUnrestrictedOutput(t *testing.T) {
 
 	t.Run("Check output of unrestricted search queries", func(t *testing.T) {
 		for _, output := range outputs {
-			answers, err := ctrl.TextIndex.Search(output.WordQuery, output.Collections, 0)
+			answers, err := ctrl.TextIndex.Search(ctrl.Context, output.WordQuery, output.Collections, nil, Options{})
 
 			if err != nil {
 				t.Errorf("Error searching in text index: %s", err)
@@ -151,7 +151,7 @@ func TestUnrestrictedOutput(t *testing.T) {
 	})
 
 	t.Run("Trying to get info that doesn't exist", func(t *testing.T) {
-		answers, err := ctrl.TextIndex.Search("qwertyuiop", []string{""}, 0)
+		answers, err := ctrl.TextIndex.Search(ctrl.Context, "qwertyuiop", []string{""}, nil, Options{})
 
 		if err != nil {
 			t.Errorf("Error searching in text index: %s", err)
@@ -228,17 +228,17 @@ func TestDatabaseUpdate(t *testing.T) {
 		"test",
 		[]string{},
 		map[string]Answer{
-			"topic/2": {2.4873344398209953, map[string][]string{
+			"topic/2": {Score: 2.4873344398209953, MatchedWords: map[string][]string{
 				"_title_original": {"test"},
 				"text":            {"test", "west"},
 				"title":           {"test"},
 			},
 			},
-			"meeting/2": {0.013346666139263209, map[string][]string{
+			"meeting/2": {Score: 0.013346666139263209, MatchedWords: map[string][]string{
 				"welcome_text": {"text"},
 			},
 			},
-			"meeting/1": {0.013346666139263209, map[string][]string{
+			"meeting/1": {Score: 0.013346666139263209, MatchedWords: map[string][]string{
 				"welcome_text": {"text"},
 			},
 			},
@@ -249,17 +249,17 @@ func TestDatabaseUpdate(t *testing.T) {
 		"test",
 		[]string{},
 		map[string]Answer{
-			"topic/2": {1.8763260236206487, map[string][]string{
+			"topic/2": {Score: 1.8763260236206487, MatchedWords: map[string][]string{
 				"_title_original": {"test"},
 				"text":            {"test", "west"},
 				"title":           {"test"},
 			},
 			},
-			"meeting/2": {0.7814626926547352, map[string][]string{
+			"meeting/2": {Score: 0.7814626926547352, MatchedWords: map[string][]string{
 				"welcome_text": {"text", "test"},
 			},
 			},
-			"meeting/1": {0.013398034798872952, map[string][]string{
+			"meeting/1": {Score: 0.013398034798872952, MatchedWords: map[string][]string{
 				"welcome_text": {"text"},
 			},
 			},
@@ -270,23 +270,23 @@ func TestDatabaseUpdate(t *testing.T) {
 		"test",
 		[]string{},
 		map[string]Answer{
-			"topic/2": {2.0287553566700622, map[string][]string{
+			"topic/2": {Score: 2.0287553566700622, MatchedWords: map[string][]string{
 				"_title_original": {"test"},
 				"text":            {"test", "west"},
 				"title":           {"test"},
 			},
 			},
-			"topic/3": {0.04828040627900243, map[string][]string{
+			"topic/3": {Score: 0.04828040627900243, MatchedWords: map[string][]string{
 				"_title_original": {"west"},
 				"text":            {"west"},
 				"title":           {"west"},
 			},
 			},
-			"meeting/2": {0.8690472848365689, map[string][]string{
+			"meeting/2": {Score: 0.8690472848365689, MatchedWords: map[string][]string{
 				"welcome_text": {"text", "test"},
 			},
 			},
-			"meeting/1": {0.014899656597235321, map[string][]string{
+			"meeting/1": {Score: 0.014899656597235321, MatchedWords: map[string][]string{
 				"welcome_text": {"text"},
 			},
 			},
@@ -302,14 +302,14 @@ func TestDatabaseUpdate(t *testing.T) {
 
 	// Update Textindex
 	ctrl.TextIndex.db.cfg.Index.Age = 0 // Force update
-	err = ctrl.TextIndex.update()
+	err = ctrl.TextIndex.update(ctrl.Context)
 
 	if err != nil {
 		t.Errorf("Error updating text index: %s", err)
 	}
 
 	t.Run("Check output before updating database", func(t *testing.T) {
-		answers, err := ctrl.TextIndex.Search(outputBeforeUdpate.WordQuery, outputBeforeUdpate.Collections, 0)
+		answers, err := ctrl.TextIndex.Search(ctrl.Context, outputBeforeUdpate.WordQuery, outputBeforeUdpate.Collections, nil, Options{})
 
 		if err != nil {
 			t.Errorf("Error searching in text index: %s", err)
@@ -329,14 +329,14 @@ func TestDatabaseUpdate(t *testing.T) {
 	}
 
 	// Update Textindex
-	err = ctrl.TextIndex.update()
+	err = ctrl.TextIndex.update(ctrl.Context)
 
 	if err != nil {
 		t.Errorf("Error updating text index: %s", err)
 	}
 
 	t.Run("Check output after updating database", func(t *testing.T) {
-		answers, err := ctrl.TextIndex.Search(outputAfterUdpate.WordQuery, outputAfterUdpate.Collections, 0)
+		answers, err := ctrl.TextIndex.Search(ctrl.Context, outputAfterUdpate.WordQuery, outputAfterUdpate.Collections, nil, Options{})
 
 		if err != nil {
 			t.Errorf("Error searching in text index: %s", err)
@@ -364,14 +364,14 @@ VALUES ('topic/3', 2);`, true)
 	}
 
 	// Update Textindex
-	err = ctrl.TextIndex.update()
+	err = ctrl.TextIndex.update(ctrl.Context)
 
 	if err != nil {
 		t.Errorf("Error updating text index: %s", err)
 	}
 
 	t.Run("Check output after updating database", func(t *testing.T) {
-		answers, err := ctrl.TextIndex.Search(outputAfterAdd.WordQuery, outputAfterAdd.Collections, 0)
+		answers, err := ctrl.TextIndex.Search(ctrl.Context, outputAfterAdd.WordQuery, outputAfterAdd.Collections, nil, Options{})
 
 		if err != nil {
 			t.Errorf("Error searching in text index: %s", err)
@@ -394,14 +394,14 @@ DELETE FROM topic_t WHERE id = 3;
 	}
 
 	// Update Textindex
-	err = ctrl.TextIndex.update()
+	err = ctrl.TextIndex.update(ctrl.Context)
 
 	if err != nil {
 		t.Errorf("Error updating text index: %s", err)
 	}
 
 	t.Run("Check output after added object has been deleted again from database", func(t *testing.T) {
-		answers, err := ctrl.TextIndex.Search(outputAfterUdpate.WordQuery, outputAfterUdpate.Collections, 0)
+		answers, err := ctrl.TextIndex.Search(ctrl.Context, outputAfterUdpate.WordQuery, outputAfterUdpate.Collections, nil, Options{})
 
 		if err != nil {
 			t.Errorf("Error searching in text index: %s", err)
@@ -481,6 +481,10 @@ func initIndex(t *testing.T) (*testTextIndexController, error) {
 		t.Errorf("creating text index failed: %s", err)
 		return nil, err
 	}
+	if err := ti.Build(ctx); err != nil {
+		t.Errorf("building text index failed: %s", err)
+		return nil, err
+	}
 
 	return &testTextIndexController{ti, pg, ctx}, nil
 }
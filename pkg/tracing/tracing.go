@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+// Package tracing sets up the OpenTelemetry tracing of the search service.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/config"
+)
+
+const (
+	serviceName = "search"
+	tracerName  = "github.com/OpenSlides/openslides-search-service"
+)
+
+// Tracer returns the tracer of the search service.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Init configures the exporter of the traces. The returned function flushes
+// and stops the exporter. Without a configured exporter only the incoming
+// trace context is propagated.
+func Init(ctx context.Context, cfg config.Tracing) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var closer func() error
+	switch cfg.Exporter {
+	case config.TracingNone:
+		return func(context.Context) error { return nil }, nil
+
+	case config.TracingOTLP:
+		otlp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("creating otlp exporter: %w", err)
+		}
+		exporter = otlp
+
+	case config.TracingFile:
+		f, err := os.Create(cfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("creating trace file %q: %w", cfg.File, err)
+		}
+		stdout, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("creating file exporter: %w", err)
+		}
+		exporter, closer = stdout, f.Close
+
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(serviceName),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer())
+		}
+		return err
+	}, nil
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package tracing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/config"
+)
+
+func TestFileExporter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "traces.json")
+
+	shutdown, err := Init(t.Context(), config.Tracing{
+		Exporter: config.TracingFile,
+		File:     file,
+	})
+	if err != nil {
+		t.Fatalf("init tracing: %v", err)
+	}
+
+	_, span := Tracer().Start(t.Context(), "test.span")
+	span.End()
+
+	if err := shutdown(t.Context()); err != nil {
+		t.Fatalf("shutdown tracing: %v", err)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("reading trace file: %v", err)
+	}
+
+	if !strings.Contains(string(content), `"Name":"test.span"`) {
+		t.Errorf("trace file does not contain the span:\n%s", content)
+	}
+}
+
+func TestUnknownExporter(t *testing.T) {
+	if _, err := Init(t.Context(), config.Tracing{Exporter: "unknown"}); err == nil {
+		t.Errorf("expected an error for an unknown exporter")
+	}
+}
@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package web
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/search"
+)
+
+// adminSecretHeader carries the shared secret of internal services.
+const adminSecretHeader = "X-Search-Admin-Secret"
+
+type forbiddenError struct {
+	err error
+}
+
+func (e forbiddenError) Error() string {
+	return fmt.Sprintf("Forbidden: %v", e.err)
+}
+
+func (e forbiddenError) Type() string {
+	return "forbidden"
+}
+
+func (e forbiddenError) StatusCode() int {
+	return http.StatusForbidden
+}
+
+type notFoundError struct {
+	err error
+}
+
+func (e notFoundError) Error() string {
+	return fmt.Sprintf("Not found: %v", e.err)
+}
+
+func (e notFoundError) Type() string {
+	return "not_found"
+}
+
+func (e notFoundError) StatusCode() int {
+	return http.StatusNotFound
+}
+
+// checkAdminSecret tells if the request carries the shared admin secret.
+// A wrong secret is an error.
+func (c *controller) checkAdminSecret(r *http.Request) (bool, error) {
+	secret := strings.TrimSpace(c.cfg.Admin.Secret)
+	given := r.Header.Get(adminSecretHeader)
+	if secret == "" || given == "" {
+		return false, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+		return false, forbiddenError{errors.New("invalid admin secret")}
+	}
+	return true, nil
+}
+
+// checkSuperadmin returns an error if the user of the authenticated
+// context is no superadmin.
+func (c *controller) checkSuperadmin(ctx context.Context) error {
+	userID := c.auth.FromContext(ctx)
+	if userID == 0 {
+		return forbiddenError{errors.New("anonymous access")}
+	}
+
+	superadmin, err := c.db.IsSuperadmin(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("checking permissions: %w", err)
+	}
+	if !superadmin {
+		return forbiddenError{errors.New("only superadmins are allowed")}
+	}
+	return nil
+}
+
+// checkAdmin returns an error if the authenticated request neither
+// carries the admin secret nor comes from a superadmin.
+func (c *controller) checkAdmin(r *http.Request) error {
+	ok, err := c.checkAdminSecret(r)
+	if err != nil || ok {
+		return err
+	}
+	return c.checkSuperadmin(r.Context())
+}
+
+// adminMiddleware lets requests pass which carry the shared admin secret
+// or come from a superadmin.
+func (c *controller) adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, err := c.checkAdminSecret(r)
+		if err != nil {
+			handleErrorWithStatus(w, err)
+			return
+		}
+		if ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, err := c.auth.Authenticate(w, r)
+		if err != nil {
+			handleErrorWithStatus(w, fmt.Errorf("authenticate request: %w", err))
+			return
+		}
+
+		if err := c.checkSuperadmin(ctx); err != nil {
+			handleErrorWithStatus(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (c *controller) adminStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := c.qs.Stats(r.Context())
+	if err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
+	writeJSON(w, stats)
+}
+
+func (c *controller) adminDocument(w http.ResponseWriter, r *http.Request) {
+	fqid := r.FormValue("fqid")
+	if col, id, found := strings.Cut(fqid, "/"); !found || col == "" || id == "" {
+		handleErrorWithStatus(w,
+			invalidRequestError{
+				fmt.Errorf("'fqid' parameter invalid: %q", fqid)})
+		return
+	}
+
+	info, err := c.qs.Inspect(r.Context(), fqid)
+	if err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
+	if info == nil {
+		handleErrorWithStatus(w, notFoundError{fmt.Errorf("%q is not indexed", fqid)})
+		return
+	}
+	writeJSON(w, info)
+}
+
+// analyzeResult contains the tokens an analyzer produced.
+type analyzeResult struct {
+	Analyzer string         `json:"analyzer"`
+	Tokens   []search.Token `json:"tokens"`
+}
+
+func (c *controller) adminAnalyze(w http.ResponseWriter, r *http.Request) {
+	text := r.FormValue("text")
+	analyzer, field := r.FormValue("analyzer"), r.FormValue("field")
+	if analyzer == "" && field == "" {
+		handleErrorWithStatus(w,
+			invalidRequestError{
+				errors.New("'analyzer' or 'field' parameter missing")})
+		return
+	}
+
+	analyzer, tokens, err := c.qs.Analyze(r.Context(), analyzer, field, r.FormValue("language"), text)
+	if err != nil {
+		// Unknown analyzers and fields are errors of the request.
+		var clientErr ClientError
+		if !errors.As(err, &clientErr) {
+			err = invalidRequestError{err}
+		}
+		handleErrorWithStatus(w, err)
+		return
+	}
+	writeJSON(w, analyzeResult{Analyzer: analyzer, Tokens: tokens})
+}
@@ -1,10 +1,108 @@
 package web
 
 import (
-	log "github.com/sirupsen/logrus"
+	"context"
+	"encoding/json"
 	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/oserror"
+	"github.com/OpenSlides/openslides-search-service/pkg/search"
 )
 
+// healthCheckTimeout limits the time the readiness probe waits for a
+// dependency.
+const healthCheckTimeout = 2 * time.Second
+
+// Health contains the dependencies reported by the readiness probe.
+type Health struct {
+	Index *search.TextIndex
+	DB    *search.Database
+	Auth  *BackgroundStatus
+}
+
+// BackgroundStatus tracks a background task like the one of the auth service.
+type BackgroundStatus struct {
+	mu        sync.Mutex
+	running   bool
+	lastErr   string
+	lastErrAt time.Time
+}
+
+// Run runs the background task and records its errors before passing them
+// to oserror.Handle.
+func (bs *BackgroundStatus) Run(ctx context.Context, task func(context.Context, func(error))) {
+	bs.mu.Lock()
+	bs.running = true
+	bs.mu.Unlock()
+
+	defer func() {
+		bs.mu.Lock()
+		bs.running = false
+		bs.mu.Unlock()
+	}()
+
+	task(ctx, func(err error) {
+		if !oserror.ContextDone(err) {
+			bs.mu.Lock()
+			bs.lastErr, bs.lastErrAt = err.Error(), time.Now()
+			bs.mu.Unlock()
+		}
+		oserror.Handle(err)
+	})
+}
+
+type backgroundState struct {
+	Running     bool       `json:"running"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+func (bs *BackgroundStatus) state() backgroundState {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	state := backgroundState{Running: bs.running, LastError: bs.lastErr}
+	if !bs.lastErrAt.IsZero() {
+		lastErrAt := bs.lastErrAt
+		state.LastErrorAt = &lastErrAt
+	}
+	return state
+}
+
+type dependencyState struct {
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+}
+
+func newDependencyState(err error) dependencyState {
+	if err != nil {
+		return dependencyState{Error: err.Error()}
+	}
+	return dependencyState{Reachable: true}
+}
+
+type indexState struct {
+	State      string     `json:"state"`
+	Documents  uint64     `json:"documents"`
+	LastUpdate *time.Time `json:"last_update,omitempty"`
+	LagSeconds float64    `json:"lag_seconds"`
+}
+
+type readiness struct {
+	Ready      bool             `json:"ready"`
+	Service    string           `json:"service"`
+	Index      indexState       `json:"index"`
+	Database   dependencyState  `json:"database"`
+	Restricter *dependencyState `json:"restricter,omitempty"`
+	Auth       *backgroundState `json:"auth,omitempty"`
+}
+
+// healthHandler is the liveness probe. It succeeds as long as the service
+// is able to answer.
 func healthHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -13,3 +111,62 @@ func healthHandler() http.HandlerFunc {
 		}
 	}
 }
+
+// readyHandler is the readiness probe. It fails while the index is built
+// or the database is not reachable. The restricter and the auth background
+// task are only reported.
+func readyHandler(health *Health, restricterURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+		defer cancel()
+
+		status := health.Index.Status()
+		ready := readiness{
+			Service: "search",
+			Index: indexState{
+				State:      status.State,
+				Documents:  status.Documents,
+				LagSeconds: status.Lag.Seconds(),
+			},
+			Database: newDependencyState(health.DB.Ping(ctx)),
+		}
+		if !status.LastUpdate.IsZero() {
+			ready.Index.LastUpdate = &status.LastUpdate
+		}
+
+		if restricterURL != "" {
+			restricter := newDependencyState(pingURL(ctx, restricterURL))
+			ready.Restricter = &restricter
+		}
+
+		if health.Auth != nil {
+			auth := health.Auth.state()
+			ready.Auth = &auth
+		}
+
+		ready.Ready = status.State == search.IndexReady && ready.Database.Reachable
+
+		w.Header().Set("Content-Type", "application/json")
+		if !ready.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(ready); err != nil {
+			log.Errorf("error: writing response failed: %v\n", err)
+		}
+	}
+}
+
+// pingURL checks if a http server answers on the given url. Any response
+// counts as reachable.
+func pingURL(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
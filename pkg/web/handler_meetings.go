@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package web
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/search"
+)
+
+const (
+	// defaultMeetingHits is the number of top hits returned per meeting.
+	defaultMeetingHits = 5
+	// maxMeetingHits limits the hits per meeting which are fetched
+	// and checked against the restricter to count the visible ones.
+	maxMeetingHits = 100
+)
+
+// meetingResult contains the top hits of a meeting and the number of the
+// hits within it. With a restricter only the hits the user is allowed to
+// see are counted, up to maxMeetingHits.
+type meetingResult struct {
+	Count uint64                 `json:"count"`
+	Hits  map[string]resultEntry `json:"hits"`
+}
+
+func (c *controller) searchMeetings(w http.ResponseWriter, r *http.Request) {
+	query := r.FormValue("q")
+	if query == "" {
+		handleErrorWithStatus(w,
+			invalidRequestError{
+				errors.New("'q' parameter missing")})
+		return
+	}
+
+	collections := c.relatedCollections(strings.Split(r.FormValue("c"), ","))
+
+	meetingIDs, err := parseIDs(r.FormValue("m"))
+	if err != nil {
+		handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("'m' parameter: %w", err)})
+		return
+	}
+
+	committeeIDs, err := parseIDs(r.FormValue("cm"))
+	if err != nil {
+		handleErrorWithStatus(w, invalidRequestError{fmt.Errorf("'cm' parameter: %w", err)})
+		return
+	}
+
+	if len(meetingIDs) == 0 && len(committeeIDs) == 0 {
+		handleErrorWithStatus(w,
+			invalidRequestError{
+				errors.New("'m' or 'cm' parameter missing")})
+		return
+	}
+
+	size := defaultMeetingHits
+	if n := r.FormValue("n"); n != "" {
+		if size, err = strconv.Atoi(n); err != nil || size < 1 || size > maxMeetingHits {
+			handleErrorWithStatus(w,
+				invalidRequestError{
+					fmt.Errorf("'n' parameter has to be between 1 and %d", maxMeetingHits)})
+			return
+		}
+	}
+
+	opts, err := optionsFromRequest(r)
+	if err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
+
+	if opts.Explain {
+		if err := c.checkAdmin(r); err != nil {
+			handleErrorWithStatus(w, err)
+			return
+		}
+	}
+
+	// Fetch more hits to be able to count the visible ones.
+	fetch := size
+	if c.cfg.Restricter.URL != "" {
+		fetch = maxMeetingHits
+	}
+
+	results, err := c.qs.QueryMeetings(r.Context(), query, collections, meetingIDs, committeeIDs, fetch, opts)
+	if err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
+
+	if c.cfg.Restricter.URL == "" {
+		// No restricter configured.
+		grouped := make(map[int]meetingResult, len(results))
+		for meetingID, result := range results {
+			hits := make(map[string]resultEntry, len(result.Answers))
+			for fqid, answer := range result.Answers {
+				hits[fqid] = resultEntry{
+					Rank:         answer.Rank,
+					MatchedWords: answer.MatchedWords,
+					Score:        &answer.Score,
+					Collapsed:    answer.Collapsed,
+				}
+			}
+			grouped[meetingID] = meetingResult{Count: result.Total, Hits: hits}
+		}
+		writeJSON(w, grouped)
+		return
+	}
+
+	restricted, err := c.restrictMeetings(r.Context(), results)
+	if err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
+
+	grouped := map[int]meetingResult{}
+	for meetingID, result := range results {
+		filtered := restricted[meetingID]
+		var visible []string
+		for fqid := range result.Answers {
+			if _, ok := filtered[fqid]; ok {
+				visible = append(visible, fqid)
+			}
+		}
+
+		if len(visible) == 0 {
+			continue
+		}
+
+		// The rank follows the requested sort order.
+		slices.SortFunc(visible, func(a, b string) int {
+			return cmp.Compare(result.Answers[a].Rank, result.Answers[b].Rank)
+		})
+
+		hits := map[string]resultEntry{}
+		for _, fqid := range visible[:min(size, len(visible))] {
+			hits[fqid] = filtered[fqid]
+		}
+		grouped[meetingID] = meetingResult{Count: uint64(len(visible)), Hits: hits}
+	}
+
+	writeJSON(w, grouped)
+}
+
+// restrictMeetings asks the restricter once for the hits of all meetings.
+// The answers of each meeting are restricted on their own, so objects found
+// in several meetings keep the rank and score of each.
+func (c *controller) restrictMeetings(ctx context.Context, results map[int]search.MeetingAnswers) (map[int]map[string]resultEntry, error) {
+	requested := map[string]search.Answer{}
+	for _, result := range results {
+		maps.Copy(requested, withCollapsedHits(result.Answers))
+	}
+
+	response, err := c.requestRestricter(ctx, requested)
+	if err != nil {
+		return nil, err
+	}
+
+	restricted := make(map[int]map[string]resultEntry, len(results))
+	for meetingID, result := range results {
+		restricted[meetingID] = restrictCollapsed(result.Answers, transformRestricterFields(result.Answers, response))
+	}
+	return restricted, nil
+}
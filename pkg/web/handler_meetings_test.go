@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/config"
+	"github.com/OpenSlides/openslides-search-service/pkg/search"
+)
+
+func TestRestrictMeetings(t *testing.T) {
+	var requests int
+	var requested []int
+	restricter := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var body []auRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding restricter request failed: %v", err)
+		}
+		for _, req := range body {
+			requested = append(requested, req.Ids...)
+		}
+		// motion/3 is not visible to the user.
+		w.Write([]byte(`{"motion/1/title": "Budget", "motion/2/title": "Budget"}`))
+	}))
+	defer restricter.Close()
+
+	c := &controller{cfg: &config.Config{Restricter: config.Restricter{URL: restricter.URL}}}
+	results := map[int]search.MeetingAnswers{
+		1: {Total: 2, Answers: map[string]search.Answer{
+			"motion/1": {Rank: 1, Score: 3},
+			"motion/3": {Rank: 2, Score: 2},
+		}},
+		2: {Total: 2, Answers: map[string]search.Answer{
+			"motion/1": {Rank: 2, Score: 1},
+			"motion/2": {Rank: 1, Score: 4},
+		}},
+	}
+
+	restricted, err := c.restrictMeetings(context.Background(), results)
+	if err != nil {
+		t.Fatalf("restricting failed: %v", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("restricter was asked %d times, expected once", requests)
+	}
+	slices.Sort(requested)
+	if expect := []int{1, 2, 3}; !slices.Equal(requested, expect) {
+		t.Errorf("requested ids %v, expected %v", requested, expect)
+	}
+
+	if _, ok := restricted[1]["motion/3"]; ok {
+		t.Errorf("invisible motion/3 is returned")
+	}
+	for _, tt := range []struct {
+		meetingID int
+		fqid      string
+		score     float64
+	}{
+		{1, "motion/1", 3},
+		{2, "motion/1", 1},
+		{2, "motion/2", 4},
+	} {
+		entry, ok := restricted[tt.meetingID][tt.fqid]
+		if !ok || entry.Score == nil || *entry.Score != tt.score {
+			t.Errorf("meeting %d: %s has score %v, expected %f", tt.meetingID, tt.fqid, entry.Score, tt.score)
+		}
+	}
+}
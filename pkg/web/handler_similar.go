@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/search"
+)
+
+const (
+	// defaultSimilarHits is the number of similar objects returned.
+	defaultSimilarHits = 10
+	// maxSimilarHits limits the number of similar objects returned.
+	maxSimilarHits = 50
+	// defaultMinSimilarity leaves out objects sharing hardly any text.
+	defaultMinSimilarity = 0.1
+)
+
+func (c *controller) searchSimilar(w http.ResponseWriter, r *http.Request) {
+	sq, err := similarQueryFromRequest(r)
+	if err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
+
+	if sq.FQID != "" {
+		if err := c.checkVisible(r.Context(), sq.FQID); err != nil {
+			handleErrorWithStatus(w, err)
+			return
+		}
+	}
+
+	answers, err := c.qs.Similar(r.Context(), sq)
+	if err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
+
+	if c.cfg.Restricter.URL == "" {
+		// No restricter configured.
+		writeJSON(w, answers)
+		return
+	}
+
+	filtered, err := c.restrict(r.Context(), answers)
+	if err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
+
+	writeJSON(w, filtered)
+}
+
+// checkVisible returns an error if the user is not allowed to see the
+// object. It is reported like an object which is not indexed.
+func (c *controller) checkVisible(ctx context.Context, fqid string) error {
+	if c.cfg.Restricter.URL == "" {
+		return nil
+	}
+	visible, err := c.restrict(ctx, map[string]search.Answer{fqid: {}})
+	if err != nil {
+		return err
+	}
+	if _, ok := visible[fqid]; !ok {
+		return search.UnknownObjectError{FQID: fqid}
+	}
+	return nil
+}
+
+// similarQueryFromRequest reads either an fqid or a draft title and text
+// with its meeting from the request.
+func similarQueryFromRequest(r *http.Request) (search.SimilarQuery, error) {
+	sq := search.SimilarQuery{
+		FQID:          r.FormValue("fqid"),
+		Size:          defaultSimilarHits,
+		MinSimilarity: defaultMinSimilarity,
+	}
+
+	for c := range strings.SplitSeq(r.FormValue("c"), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			sq.Collections = append(sq.Collections, c)
+		}
+	}
+
+	draft := strings.TrimSpace(r.FormValue("title") + "\n" + r.FormValue("text"))
+	switch {
+	case sq.FQID != "" && draft != "":
+		return sq, invalidRequestError{
+			errors.New("either 'fqid' or 'title' and 'text' parameters can be given")}
+	case sq.FQID != "":
+		if col, id, found := strings.Cut(sq.FQID, "/"); !found || col == "" || id == "" {
+			return sq, invalidRequestError{
+				fmt.Errorf("'fqid' parameter invalid: %q", sq.FQID)}
+		}
+	case draft != "":
+		meetingID, err := strconv.Atoi(r.FormValue("m"))
+		if err != nil || meetingID <= 0 {
+			return sq, invalidRequestError{
+				errors.New("'m' parameter has to be a meeting id for drafts")}
+		}
+		sq.Text = draft
+		sq.MeetingID = meetingID
+	default:
+		return sq, invalidRequestError{
+			errors.New("'fqid' or 'title' and 'text' parameters missing")}
+	}
+
+	if n := r.FormValue("n"); n != "" {
+		size, err := strconv.Atoi(n)
+		if err != nil || size < 1 || size > maxSimilarHits {
+			return sq, invalidRequestError{
+				fmt.Errorf("'n' parameter has to be between 1 and %d", maxSimilarHits)}
+		}
+		sq.Size = size
+	}
+
+	if v := r.FormValue("min_similarity"); v != "" {
+		minSimilarity, err := strconv.ParseFloat(v, 64)
+		if err != nil || minSimilarity < 0 || minSimilarity > 1 {
+			return sq, invalidRequestError{
+				errors.New("'min_similarity' parameter has to be between 0 and 1")}
+		}
+		sq.MinSimilarity = minSimilarity
+	}
+
+	return sq, nil
+}
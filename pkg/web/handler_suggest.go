@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/search"
+)
+
+const (
+	// defaultSuggestions is the number of keywords and objects suggested.
+	defaultSuggestions = 10
+	// maxSuggestions limits the number of keywords and objects suggested.
+	maxSuggestions = 50
+)
+
+func (c *controller) suggest(w http.ResponseWriter, r *http.Request) {
+	fqid := r.FormValue("fqid")
+	if col, id, found := strings.Cut(fqid, "/"); !found || col == "" || id == "" {
+		handleErrorWithStatus(w,
+			invalidRequestError{
+				fmt.Errorf("'fqid' parameter invalid: %q", fqid)})
+		return
+	}
+
+	size := defaultSuggestions
+	if n := r.FormValue("n"); n != "" {
+		var err error
+		if size, err = strconv.Atoi(n); err != nil || size < 1 || size > maxSuggestions {
+			handleErrorWithStatus(w,
+				invalidRequestError{
+					fmt.Errorf("'n' parameter has to be between 1 and %d", maxSuggestions)})
+			return
+		}
+	}
+
+	if err := c.checkVisible(r.Context(), fqid); err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
+
+	suggestions, err := c.qs.Suggest(r.Context(), fqid, size)
+	if err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
+
+	if c.cfg.Restricter.URL != "" {
+		if suggestions.Objects, err = c.restrictSuggested(r.Context(), suggestions.Objects); err != nil {
+			handleErrorWithStatus(w, err)
+			return
+		}
+	}
+
+	writeJSON(w, suggestions)
+}
+
+// restrictSuggested removes the suggested objects and the similar objects the
+// user is not allowed to see and adjusts the scores. Objects only suggested by
+// invisible similar objects are removed.
+func (c *controller) restrictSuggested(ctx context.Context, objects []search.SuggestedObject) ([]search.SuggestedObject, error) {
+	requested := map[string]search.Answer{}
+	for _, object := range objects {
+		requested[object.FQID] = search.Answer{}
+		for fqid := range object.From {
+			requested[fqid] = search.Answer{}
+		}
+	}
+	if len(requested) == 0 {
+		return objects, nil
+	}
+
+	visible, err := c.restrict(ctx, requested)
+	if err != nil {
+		return nil, err
+	}
+
+	restricted := make([]search.SuggestedObject, 0, len(objects))
+	for _, object := range objects {
+		if _, ok := visible[object.FQID]; !ok {
+			continue
+		}
+		object.Score = 0
+		for fqid, similarity := range object.From {
+			if _, ok := visible[fqid]; !ok {
+				delete(object.From, fqid)
+				continue
+			}
+			object.Score += similarity
+		}
+		if len(object.From) > 0 {
+			restricted = append(restricted, object)
+		}
+	}
+	search.SortSuggestedObjects(restricted)
+	return restricted, nil
+}
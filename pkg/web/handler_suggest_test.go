@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/config"
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+	"github.com/OpenSlides/openslides-search-service/pkg/search"
+)
+
+func TestRestrictSuggested(t *testing.T) {
+	requested := map[string][]int{}
+	restricter := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []auRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding restricter request failed: %v", err)
+		}
+		for _, req := range body {
+			if len(req.Fields) == 0 {
+				t.Errorf("no fields requested for %s", req.Collection)
+			}
+			requested[req.Collection] = append(requested[req.Collection], req.Ids...)
+		}
+		// tag/2 and motion/2 are not visible to the user.
+		w.Write([]byte(`{"tag/1/id": 1, "motion_category/1/id": 1, "motion/1/title": "Budget"}`))
+	}))
+	defer restricter.Close()
+
+	c := &controller{
+		cfg: &config.Config{Restricter: config.Restricter{URL: restricter.URL}},
+		reqFields: map[string]map[string]*meta.CollectionRelation{
+			"motion": {"title": nil},
+		},
+	}
+	objects := []search.SuggestedObject{
+		{FQID: "tag/1", Field: "tag_ids", Score: 0.8, From: map[string]float64{"motion/1": 0.5, "motion/2": 0.3}},
+		{FQID: "tag/2", Field: "tag_ids", Score: 0.5, From: map[string]float64{"motion/1": 0.5}},
+		{FQID: "motion_category/1", Field: "category_id", Score: 0.3, From: map[string]float64{"motion/2": 0.3}},
+	}
+
+	restricted, err := c.restrictSuggested(context.Background(), objects)
+	if err != nil {
+		t.Fatalf("restricting failed: %v", err)
+	}
+
+	for col, expect := range map[string][]int{"tag": {1, 2}, "motion_category": {1}, "motion": {1, 2}} {
+		slices.Sort(requested[col])
+		if !slices.Equal(requested[col], expect) {
+			t.Errorf("requested %s ids %v, expected %v", col, requested[col], expect)
+		}
+	}
+
+	if len(restricted) != 1 {
+		t.Fatalf("got %d suggested objects, expected only tag/1", len(restricted))
+	}
+	if got := restricted[0]; got.FQID != "tag/1" || got.Score != 0.5 || len(got.From) != 1 {
+		t.Errorf("got %v, expected tag/1 suggested by motion/1", got)
+	}
+}
@@ -12,17 +12,21 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
 	"github.com/OpenSlides/openslides-go/auth"
 	"github.com/OpenSlides/openslides-search-service/pkg/config"
 	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+	"github.com/OpenSlides/openslides-search-service/pkg/metrics"
 	"github.com/OpenSlides/openslides-search-service/pkg/oserror"
 	"github.com/OpenSlides/openslides-search-service/pkg/search"
 )
@@ -31,6 +35,7 @@ type controller struct {
 	cfg       *config.Config
 	auth      *auth.Auth
 	qs        *search.QueryServer
+	db        *search.Database
 	reqFields map[string]map[string]*meta.CollectionRelation
 	collRel   map[string]map[string]struct{}
 }
@@ -51,11 +56,17 @@ func (c *controller) autoupdateRequestFromFQIDs(answers map[string]search.Answer
 		}
 
 		if _, ok := collIdxMap[collection]; !ok {
+			fields, ok := c.reqFields[collection]
+			if !ok {
+				// Objects of collections which are not searched, like
+				// suggested tags, are only checked for visibility.
+				fields = map[string]*meta.CollectionRelation{"id": nil}
+			}
 			collIdxMap[collection] = len(req)
 			req = append(req, auRequest{
 				Ids:        []int{},
 				Collection: collection,
-				Fields:     c.reqFields[collection],
+				Fields:     fields,
 			})
 		}
 
@@ -97,82 +108,312 @@ func (c *controller) search(w http.ResponseWriter, r *http.Request) {
 
 	collections := c.relatedCollections(strings.Split(r.FormValue("c"), ","))
 
-	meeting, _ := strconv.Atoi(r.FormValue("m"))
-	answers, err := c.qs.Query(query, collections, meeting)
+	scope, err := scopeFromRequest(r)
 	if err != nil {
 		handleErrorWithStatus(w, err)
 		return
 	}
 
-	if c.cfg.Restricter.URL != "" {
-
-		userID := c.auth.FromContext(r.Context())
-		requestBody := c.autoupdateRequestFromFQIDs(answers)
+	opts, err := optionsFromRequest(r)
+	if err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
 
-		if len(requestBody) == 0 {
-			if _, err := w.Write([]byte("{}")); err != nil {
-				log.Errorf("error: writing response failed: %v\n", err)
-			}
+	if opts.Explain {
+		if err := c.checkAdmin(r); err != nil {
+			handleErrorWithStatus(w, err)
 			return
 		}
+	}
 
-		body, err := json.Marshal(&requestBody)
-		if err != nil {
-			handleErrorWithStatus(w, err)
-			return
+	answers, err := c.qs.Query(r.Context(), query, collections, scope, opts)
+	if err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
+
+	if c.cfg.Restricter.URL == "" {
+		// No restricter configured.
+		writeJSON(w, answers)
+		return
+	}
+
+	filtered, err := c.restrict(r.Context(), answers)
+	if err != nil {
+		handleErrorWithStatus(w, err)
+		return
+	}
+
+	writeJSON(w, filtered)
+}
+
+// restrict asks the restricter for the content of the answers the
+// requesting user is allowed to see.
+func (c *controller) restrict(ctx context.Context, answers map[string]search.Answer) (map[string]resultEntry, error) {
+	response, err := c.requestRestricter(ctx, withCollapsedHits(answers))
+	if err != nil {
+		return nil, err
+	}
+	return restrictCollapsed(answers, transformRestricterFields(answers, response)), nil
+}
+
+// withCollapsedHits adds the collapsed hits to the answers. Collapsed hits
+// are only shown if visible themselves.
+func withCollapsedHits(answers map[string]search.Answer) map[string]search.Answer {
+	requested := maps.Clone(answers)
+	for _, answer := range answers {
+		for _, hit := range answer.Collapsed {
+			if _, ok := requested[hit.FQID]; !ok {
+				requested[hit.FQID] = search.Answer{}
+			}
 		}
+	}
+	return requested
+}
 
-		urlParams := fmt.Sprintf("?user_id=%d&single=1", userID)
-		req, err := http.NewRequest("POST", c.cfg.Restricter.URL+urlParams, bytes.NewReader(body))
-		if err != nil {
-			handleErrorWithStatus(w, err)
-			return
+// requestRestricter returns the fields of the requested objects the
+// requesting user is allowed to see, keyed by collection/id/field.
+func (c *controller) requestRestricter(ctx context.Context, requested map[string]search.Answer) (map[string]any, error) {
+	userID := c.auth.FromContext(ctx)
+
+	requestBody := c.autoupdateRequestFromFQIDs(requested)
+
+	if len(requestBody) == 0 {
+		return map[string]any{}, nil
+	}
+
+	body, err := json.Marshal(&requestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	urlParams := fmt.Sprintf("?user_id=%d&single=1", userID)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.cfg.Restricter.URL+urlParams, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = http.Header{
+		"Content-Type": {"application/json"},
+	}
+
+	client := http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	start := time.Now()
+	resp, err := client.Do(req)
+	metrics.RestricterDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.RestricterErrors.Inc()
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		metrics.RestricterErrors.Inc()
+		return nil, invalidRequestError{
+			fmt.Errorf("restricter call failed: %q (%d)",
+				resp.Status, resp.StatusCode)}
+	}
+
+	return decodeRestricterResponse(resp.Body)
+}
+
+// restrictCollapsed removes the collapsed hits the user is not allowed to
+// see together with their share of the score. Objects only found by such
+// hits are removed. Entries of collapsed hits are only requested to check
+// their visibility and left out. All other entries, like related objects
+// returned by the restricter, are kept as they are.
+func restrictCollapsed(answers map[string]search.Answer, transformed map[string]resultEntry) map[string]resultEntry {
+	collapsed := map[string]struct{}{}
+	for _, answer := range answers {
+		for _, hit := range answer.Collapsed {
+			collapsed[hit.FQID] = struct{}{}
 		}
+	}
+	if len(collapsed) == 0 {
+		return transformed
+	}
 
-		req.Header = http.Header{
-			"Content-Type": {"application/json"},
+	restricted := make(map[string]resultEntry, len(transformed))
+	for fqid, entry := range transformed {
+		answer, ok := answers[fqid]
+		if !ok {
+			if _, ok := collapsed[fqid]; !ok {
+				restricted[fqid] = entry
+			}
+			continue
+		}
+		if len(answer.Collapsed) == 0 {
+			restricted[fqid] = entry
+			continue
 		}
 
-		client := http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			handleErrorWithStatus(w, err)
-			return
+		score := answer.Score
+		for _, hit := range answer.Collapsed {
+			if _, ok := transformed[hit.FQID]; ok {
+				entry.Collapsed = append(entry.Collapsed, hit)
+			} else {
+				score -= hit.Score
+			}
 		}
-		if resp.StatusCode != http.StatusOK {
-			handleErrorWithStatus(w,
-				invalidRequestError{
-					fmt.Errorf("restricter call failed: %q (%d)",
-						resp.Status, resp.StatusCode)})
-			return
+		if len(entry.Collapsed) == 0 && len(answer.MatchedWords) == 0 {
+			continue
 		}
-		defer resp.Body.Close()
-		w.Header().Set("Content-Type", "application/json")
+		entry.Score = &score
+		restricted[fqid] = entry
+	}
+	return restricted
+}
 
-		filteredResp, err := transformRestricterResponse(answers, resp.Body)
+// writeJSON writes v as json response.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("error: %v\n", err)
+	}
+}
+
+// scopeParams maps the request parameters to the scope kinds they restrict.
+var scopeParams = []struct {
+	param string
+	kind  string
+}{
+	{"m", meta.ScopeMeeting},
+	{"cm", meta.ScopeCommittee},
+	{"o", meta.ScopeOrganization},
+}
+
+// scopeFromRequest extracts the comma separated scope ids from the request.
+func scopeFromRequest(r *http.Request) (search.Scope, error) {
+	scope := search.Scope{}
+	for _, sp := range scopeParams {
+		ids, err := parseIDs(r.FormValue(sp.param))
 		if err != nil {
-			handleErrorWithStatus(w, err)
-			return
+			return nil, invalidRequestError{
+				fmt.Errorf("'%s' parameter: %w", sp.param, err)}
 		}
+		scope.Add(sp.kind, ids...)
+	}
+	return scope, nil
+}
 
-		if _, err := w.Write(filteredResp); err != nil {
-			log.Errorf("error: writing response failed: %v\n", err)
+// optionsFromRequest extracts the optional search settings from the request.
+func optionsFromRequest(r *http.Request) (search.Options, error) {
+	var opts search.Options
+	if v := r.FormValue("include_archived"); v != "" {
+		includeArchived, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, invalidRequestError{
+				fmt.Errorf("'include_archived' parameter: %w", err)}
 		}
-
-		return
+		opts.IncludeArchived = includeArchived
 	}
+	if v := r.FormValue("explain"); v != "" {
+		explain, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, invalidRequestError{
+				fmt.Errorf("'explain' parameter: %w", err)}
+		}
+		opts.Explain = explain
+	}
+	for _, p := range []struct {
+		param  string
+		target *time.Time
+		endOf  bool
+	}{
+		{"from", &opts.From, false},
+		{"to", &opts.To, true},
+	} {
+		if v := r.FormValue(p.param); v != "" {
+			t, err := parseTime(v, p.endOf)
+			if err != nil {
+				return opts, invalidRequestError{
+					fmt.Errorf("'%s' parameter: %w", p.param, err)}
+			}
+			*p.target = t
+		}
+	}
+	if !opts.From.IsZero() && !opts.To.IsZero() && opts.To.Before(opts.From) {
+		return opts, invalidRequestError{
+			errors.New("'to' parameter is before 'from'")}
+	}
+	if v := r.FormValue("sort"); v != "" {
+		for field := range strings.SplitSeq(v, ",") {
+			descending := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+			if field == "" {
+				return opts, invalidRequestError{
+					errors.New("'sort' parameter contains an empty field")}
+			}
+			opts.Sort = append(opts.Sort, search.SortField{Field: field, Descending: descending})
+		}
+	}
+	if v := r.FormValue("collapse"); v != "" {
+		collapse, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, invalidRequestError{
+				fmt.Errorf("'collapse' parameter: %w", err)}
+		}
+		opts.Collapse = collapse
+	}
+	if v := r.FormValue("recency"); v != "" {
+		days, err := strconv.ParseFloat(v, 64)
+		if err != nil || days <= 0 {
+			return opts, invalidRequestError{
+				errors.New("'recency' parameter has to be a positive number of days")}
+		}
+		opts.Recency = time.Duration(days * float64(24*time.Hour))
+	}
+	return opts, nil
+}
 
-	// No restricter configured.
-
-	w.Header().Set("Content-Type", "application/json")
+// parseTime parses a timestamp in RFC 3339 or a date. A date is the start
+// of the day in UTC, or its end if endOf is set.
+func parseTime(s string, endOf bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected a date or RFC 3339", s)
+	}
+	if endOf {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
 
-	if err := json.NewEncoder(w).Encode(answers); err != nil {
-		log.Errorf("error: %v\n", err)
+// parseIDs parses a comma separated list of ids.
+func parseIDs(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
 	}
+	var ids []int
+	for part := range strings.SplitSeq(s, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// resultEntry is the restricted content of an answer.
+type resultEntry struct {
+	Content      map[string]any      `json:"content,omitempty"`
+	Rank         int                 `json:"rank,omitempty"`
+	MatchedWords map[string][]string `json:"matched_by,omitempty"`
+	Score        *float64            `json:"score,omitempty"`
+
+	Explanation   *search.Explanation   `json:"explanation,omitempty"`
+	Contributions map[string]float64    `json:"contributions,omitempty"`
+	Collapsed     []search.CollapsedHit `json:"collapsed,omitempty"`
+	Passages      []string              `json:"passages,omitempty"`
 }
 
-// transforms the autoupdate response to per fqid objects
-func transformRestricterResponse(answers map[string]search.Answer, body io.ReadCloser) ([]byte, error) {
+// decodeRestricterResponse reads the fields returned by the restricter.
+func decodeRestricterResponse(body io.Reader) (map[string]any, error) {
 	respBody, err := io.ReadAll(body)
 	if err != nil {
 		return nil, err
@@ -182,13 +423,12 @@ func transformRestricterResponse(answers map[string]search.Answer, body io.ReadC
 	if err := json.Unmarshal(respBody, &restricterResponse); err != nil {
 		return nil, err
 	}
+	return restricterResponse, nil
+}
 
-	type resultEntry struct {
-		Content      map[string]any      `json:"content"`
-		MatchedWords map[string][]string `json:"matched_by,omitempty"`
-		Score        *float64            `json:"score,omitempty"`
-	}
-
+// transformRestricterFields groups the fields returned by the restricter
+// per fqid and adds the search results of the answers.
+func transformRestricterFields(answers map[string]search.Answer, restricterResponse map[string]any) map[string]resultEntry {
 	transformed := make(map[string]resultEntry)
 	for k, v := range restricterResponse {
 		parts := strings.Split(k, "/")
@@ -198,28 +438,22 @@ func transformRestricterResponse(answers map[string]search.Answer, body io.ReadC
 			field := parts[2]
 
 			if _, ok := transformed[fqid]; !ok {
-				var score *float64
-				var matchedWords map[string][]string
+				entry := resultEntry{Content: make(map[string]any)}
 				if val, ok := answers[fqid]; ok {
-					score = &val.Score
-					matchedWords = val.MatchedWords
-				}
-				transformed[fqid] = resultEntry{
-					Content:      make(map[string]any),
-					MatchedWords: matchedWords,
-					Score:        score,
+					entry.Rank = val.Rank
+					entry.Score = &val.Score
+					entry.MatchedWords = val.MatchedWords
+					entry.Explanation = val.Explanation
+					entry.Contributions = val.Contributions
+					entry.Passages = val.Passages
 				}
+				transformed[fqid] = entry
 			}
 			transformed[fqid].Content[field] = v
 		}
 	}
 
-	transformedContent, err := json.Marshal(transformed)
-	if err != nil {
-		return nil, err
-	}
-
-	return transformedContent, nil
+	return transformed
 }
 
 func authMiddleware(next http.Handler, auth *auth.Auth) http.Handler {
@@ -320,12 +554,14 @@ func Run(
 	qs *search.QueryServer,
 	reqFields map[string]map[string]*meta.CollectionRelation,
 	collRel map[string]map[string]struct{},
+	health *Health,
 ) error {
 
 	c := controller{
 		cfg:       cfg,
 		auth:      auth,
 		qs:        qs,
+		db:        health.DB,
 		reqFields: reqFields,
 		collRel:   collRel,
 	}
@@ -336,16 +572,57 @@ func Run(
 		"/system/search",
 		authMiddleware(http.HandlerFunc(c.search), auth))
 
+	mux.Handle(
+		"/system/search/meetings",
+		authMiddleware(http.HandlerFunc(c.searchMeetings), auth))
+
+	mux.Handle(
+		"/system/search/similar",
+		authMiddleware(http.HandlerFunc(c.searchSimilar), auth))
+
+	mux.Handle(
+		"/system/search/suggest",
+		authMiddleware(http.HandlerFunc(c.suggest), auth))
+
+	mux.Handle(
+		"/system/search/admin/stats",
+		c.adminMiddleware(http.HandlerFunc(c.adminStats)))
+
+	mux.Handle(
+		"/system/search/admin/document",
+		c.adminMiddleware(http.HandlerFunc(c.adminDocument)))
+
+	mux.Handle(
+		"/system/search/admin/analyze",
+		c.adminMiddleware(http.HandlerFunc(c.adminAnalyze)))
+
+	mux.Handle(
+		"/system/search/metrics",
+		metrics.Handler())
+
 	mux.Handle(
 		"/system/search/health",
 		http.HandlerFunc(healthHandler()))
 
+	mux.Handle(
+		"/system/search/health/live",
+		http.HandlerFunc(healthHandler()))
+
+	mux.Handle(
+		"/system/search/health/ready",
+		http.HandlerFunc(readyHandler(health, cfg.Restricter.URL)))
+
+	handler := otelhttp.NewHandler(mux, "search",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.URL.Path
+		}))
+
 	addr := fmt.Sprintf("%s:%d", cfg.Web.Host, cfg.Web.Port)
 	log.Infof("listen web on %s\n", addr)
 
 	s := &http.Server{
 		Addr:        addr,
-		Handler:     mux,
+		Handler:     handler,
 		BaseContext: func(net.Listener) context.Context { return ctx },
 	}
 
@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2022 Since 2011 Authors of OpenSlides, see https://github.com/OpenSlides/OpenSlides/blob/master/AUTHORS
+//
+// SPDX-License-Identifier: MIT
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/OpenSlides/openslides-search-service/pkg/meta"
+	"github.com/OpenSlides/openslides-search-service/pkg/search"
+)
+
+func TestRestrictCollapsed(t *testing.T) {
+	// meeting/1 is a related object returned by the restricter and
+	// agenda_item/2 is not visible to the user.
+	response := `{
+		"topic/1/title": "Budget",
+		"agenda_item/1/item_number": "TOP 1",
+		"motion/1/title": "Budget",
+		"meeting/1/name": "Annual meeting"
+	}`
+
+	for _, tt := range []struct {
+		name    string
+		answers map[string]search.Answer
+		expect  map[string]float64
+	}{
+		{
+			name: "without collapsing",
+			answers: map[string]search.Answer{
+				"topic/1":  {Rank: 1, Score: 3},
+				"motion/1": {Rank: 2, Score: 2},
+			},
+			expect: map[string]float64{"topic/1": 3, "motion/1": 2, "meeting/1": -1, "agenda_item/1": -1},
+		},
+		{
+			name: "collapsed",
+			answers: map[string]search.Answer{
+				"topic/1": {Rank: 1, Score: 4.5, MatchedWords: map[string][]string{"title": {"budget"}}, Collapsed: []search.CollapsedHit{
+					{FQID: "agenda_item/1", Score: 1},
+					{FQID: "agenda_item/2", Score: 0.5},
+				}},
+				"motion/1": {Rank: 2, Score: 2},
+			},
+			expect: map[string]float64{"topic/1": 4, "motion/1": 2, "meeting/1": -1},
+		},
+		{
+			name: "only hidden collapsed hits",
+			answers: map[string]search.Answer{
+				"topic/1": {Rank: 1, Score: 0.5, MatchedWords: map[string][]string{}, Collapsed: []search.CollapsedHit{
+					{FQID: "agenda_item/2", Score: 0.5},
+				}},
+				"motion/1": {Rank: 2, Score: 2},
+			},
+			expect: map[string]float64{"motion/1": 2, "meeting/1": -1, "agenda_item/1": -1},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := decodeRestricterResponse(strings.NewReader(response))
+			if err != nil {
+				t.Fatalf("decoding response failed: %v", err)
+			}
+			got := restrictCollapsed(tt.answers, transformRestricterFields(tt.answers, fields))
+
+			var fqids, expected []string
+			for fqid := range got {
+				fqids = append(fqids, fqid)
+			}
+			for fqid := range tt.expect {
+				expected = append(expected, fqid)
+			}
+			slices.Sort(fqids)
+			slices.Sort(expected)
+			if !slices.Equal(fqids, expected) {
+				t.Fatalf("got entries %v, expected %v", fqids, expected)
+			}
+
+			for fqid, score := range tt.expect {
+				entry := got[fqid]
+				if score == -1 {
+					// Related objects keep no score.
+					if entry.Score != nil {
+						t.Errorf("%s has score %f, expected none", fqid, *entry.Score)
+					}
+					continue
+				}
+				if entry.Score == nil || *entry.Score != score {
+					t.Errorf("%s has score %v, expected %f", fqid, entry.Score, score)
+				}
+			}
+
+			if c := got["topic/1"].Collapsed; len(c) > 0 && (len(c) != 1 || c[0].FQID != "agenda_item/1") {
+				t.Errorf("topic/1 collapsed %v, expected only agenda_item/1", c)
+			}
+		})
+	}
+}
+
+func TestScopeFromRequest(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		query  string
+		expect search.Scope
+	}{
+		{
+			name:   "no scope",
+			query:  "q=budget",
+			expect: search.Scope{},
+		},
+		{
+			name:   "meetings",
+			query:  "q=budget&m=1,2",
+			expect: search.Scope{meta.ScopeMeeting: {1, 2}},
+		},
+		{
+			name:  "all kinds",
+			query: "q=budget&m=1&cm=2&o=1",
+			expect: search.Scope{
+				meta.ScopeMeeting:      {1},
+				meta.ScopeCommittee:    {2},
+				meta.ScopeOrganization: {1},
+			},
+		},
+		{
+			name:   "ids lower than one",
+			query:  "q=budget&m=0&cm=-1,3",
+			expect: search.Scope{meta.ScopeCommittee: {3}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/search?"+tt.query, nil)
+			got, err := scopeFromRequest(r)
+			if err != nil {
+				t.Fatalf("parsing scope failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("got %v, expected %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestSearchInvalidScope(t *testing.T) {
+	for _, query := range []string{"m=abc", "m=1,x", "cm=1.5", "o=one"} {
+		t.Run(query, func(t *testing.T) {
+			c := &controller{}
+			w := httptest.NewRecorder()
+			c.search(w, httptest.NewRequest(http.MethodGet, "/search?q=budget&"+query, nil))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("got status %d, expected %d", w.Code, http.StatusBadRequest)
+			}
+			if body := w.Body.String(); !strings.Contains(body, "parameter") {
+				t.Errorf("got body %s, expected the invalid parameter", body)
+			}
+		})
+	}
+}